@@ -0,0 +1,383 @@
+// Command arena has bots play each other over and over to see how rules and
+// strategies hold up.
+//
+// Every game is dealt from its own seed, so a run with the same flags deals
+// the same games. Bots that think against the clock, like the medium and
+// hard ones, may still play them differently.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/cetacean/magiism/dominos/ai"
+	"github.com/cetacean/magiism/dominos/game"
+)
+
+var (
+	games   = flag.Int("games", 1000, "how many games to play")
+	seed    = flag.Int64("seed", 1, "seed for the first game, every other game uses the next one")
+	bots    = flag.String("bots", "greedy,random", "comma-separated list of the bots in each seat (kinds: "+strings.Join(ai.Names(), ", ")+", or "+ai.ExecPrefix+"command for an engine)")
+	rules   = flag.String("rules", game.Standard.Name, "rule set to play by (one of: "+strings.Join(ruleNames(), ", ")+")")
+	set     = flag.Int("set", 0, "highest double in the set, overriding the rule set")
+	rounds  = flag.Int("rounds", 0, "rounds to play in each game, zero plays whole matches")
+	rotate  = flag.Bool("rotate", true, "move every bot one seat along after each game")
+	budget  = flag.Duration("budget", 10*time.Millisecond, "how long medium and hard bots may think per move")
+	timeout = flag.Duration("timeout", time.Second, "how long engines may think per move")
+	workers = flag.Int("workers", runtime.NumCPU(), "how many games to play at once")
+)
+
+func ruleNames() []string {
+	var result []string
+	for name := range game.RuleSets {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func main() {
+	flag.Parse()
+
+	rs, ok := game.RuleSets[*rules]
+	if !ok {
+		log.Fatalf("no rule set called %q", *rules)
+	}
+	if *set > 0 {
+		rs.Set = *set
+	}
+
+	kinds := strings.Split(*bots, ",")
+	err := rs.CheckPlayers(len(kinds))
+	if err != nil {
+		log.Fatalf("%d seats: %v", len(kinds), err)
+	}
+	for _, kind := range kinds {
+		bot, err := ai.New(kind, nil)
+		if err != nil {
+			log.Fatalf("%s: %v", kind, err)
+		}
+		if e, ok := bot.(*ai.Engine); ok {
+			log.Printf("%s is %q", kind, e.Name)
+			e.Close()
+		}
+	}
+
+	var (
+		wg      sync.WaitGroup
+		jobs    = make(chan int)
+		results = make(chan outcome)
+	)
+
+	// Games already being played are finished after one goes wrong, so that
+	// every engine gets closed, but no more are started.
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+
+	for i := 0; i < *workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := range jobs {
+				res, err := play(n, rs, kinds)
+				if err != nil {
+					err = fmt.Errorf("game %d (seed %d): %v", n, *seed+int64(n), err)
+				}
+				results <- outcome{res: res, err: err}
+			}
+		}()
+	}
+
+	go func() {
+		defer close(jobs)
+		for n := 0; n < *games; n++ {
+			select {
+			case jobs <- n:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	began := time.Now()
+	st := newStats(len(kinds))
+	var failed error
+	for o := range results {
+		if o.err != nil {
+			if failed == nil {
+				failed = o.err
+			}
+			stop()
+			continue
+		}
+		st.add(o.res)
+	}
+	if failed != nil {
+		log.Fatal(failed)
+	}
+
+	st.report(os.Stdout)
+	fmt.Printf("\n%d games in %s\n", st.games, time.Since(began).Truncate(time.Millisecond))
+}
+
+// seat is a player in a game of the arena.
+type seat struct {
+	ID   string
+	Kind string
+}
+
+// round is what happened in a single round.
+type round struct {
+	Turns   int
+	Plays   int
+	Markers int
+	Blocked bool
+	Pips    map[string]int // Left in every hand when the round ended.
+}
+
+// result is what happened in a single game.
+type result struct {
+	Seats  []seat
+	Rounds []round
+	Scores map[string]int
+}
+
+// outcome is the result of a game a worker played, or why it couldn't.
+type outcome struct {
+	res *result
+	err error
+}
+
+// play has the bots play the nth game.
+func play(n int, rs game.RuleSet, kinds []string) (*result, error) {
+	res := &result{}
+	table := &ai.Table{Seats: map[string]ai.Player{}}
+	defer table.Close()
+	botRand := rand.New(rand.NewSource(*seed + int64(n) + 1<<32))
+
+	var ids []string
+	for i := range kinds {
+		kind := kinds[i]
+		if *rotate {
+			kind = kinds[(i+n)%len(kinds)]
+		}
+
+		id := fmt.Sprintf("seat%d", i+1)
+		bot, err := ai.New(kind, botRand)
+		if err != nil {
+			return nil, err
+		}
+		switch b := bot.(type) {
+		case *ai.Strong:
+			b.Budget = *budget
+		case *ai.Engine:
+			b.Timeout = *timeout
+		}
+
+		ids = append(ids, id)
+		table.Seats[id] = bot
+		res.Seats = append(res.Seats, seat{ID: id, Kind: kind})
+	}
+
+	g, err := game.NewWithRand(ids, rs, rand.New(rand.NewSource(*seed+int64(n))))
+	if err != nil {
+		return nil, err
+	}
+
+	// A marker goes up whenever a player's train does.
+	var cur round
+	up := map[string]bool{}
+	table.Watch = func(e game.Event, r *game.Response, err error) {
+		if err == game.ErrEndOfTurn {
+			cur.Turns++
+		}
+		if e.Action == game.PlayDomino && r != nil && r.Success {
+			cur.Plays++
+		}
+		for _, p := range g.Players {
+			if p.Path.Train && !up[p.ID] {
+				cur.Markers++
+			}
+			up[p.ID] = p.Path.Train
+		}
+	}
+
+	for {
+		_, err := table.Play(context.Background(), g)
+		if err != nil {
+			return nil, err
+		}
+
+		switch g.Phase {
+		case game.RoundOver:
+		case game.MatchOver:
+			res.Scores = g.Scores
+			return res, nil
+		default:
+			return nil, fmt.Errorf("bots stopped playing in the %s phase", g.Phase)
+		}
+
+		cur.Blocked = true
+		cur.Pips = map[string]int{}
+		for _, p := range g.Players {
+			for _, d := range p.Hand {
+				cur.Pips[p.ID] += d.Value()
+			}
+			if len(p.Hand) == 0 {
+				cur.Blocked = false
+			}
+		}
+		res.Rounds = append(res.Rounds, cur)
+		cur, up = round{}, map[string]bool{}
+
+		if *rounds > 0 && len(res.Rounds) >= *rounds {
+			res.Scores = g.Scores
+			return res, nil
+		}
+
+		err = g.NextRound()
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// stats adds up the results of every game.
+type stats struct {
+	games, rounds int
+	turns, plays  int
+	markers       int
+	blocked       int
+
+	seatWins  []float64
+	kindWins  map[string]float64
+	kindGames map[string]int
+	kindPips  map[string]int
+	kindSeats map[string]int // Rounds played by each kind of bot.
+	pips      []int          // Left in every hand at the end of every round.
+}
+
+func newStats(seats int) *stats {
+	return &stats{
+		seatWins:  make([]float64, seats),
+		kindWins:  map[string]float64{},
+		kindGames: map[string]int{},
+		kindPips:  map[string]int{},
+		kindSeats: map[string]int{},
+	}
+}
+
+func (st *stats) add(res *result) {
+	st.games++
+
+	kinds := map[string]string{}
+	for _, s := range res.Seats {
+		kinds[s.ID] = s.Kind
+		st.kindGames[s.Kind]++
+	}
+
+	// Lowest score wins, ties share the win.
+	var winners []int
+	for i, s := range res.Seats {
+		switch {
+		case len(winners) == 0 || res.Scores[s.ID] < res.Scores[res.Seats[winners[0]].ID]:
+			winners = []int{i}
+		case res.Scores[s.ID] == res.Scores[res.Seats[winners[0]].ID]:
+			winners = append(winners, i)
+		}
+	}
+	for _, i := range winners {
+		st.seatWins[i] += 1 / float64(len(winners))
+		st.kindWins[res.Seats[i].Kind] += 1 / float64(len(winners))
+	}
+
+	for _, r := range res.Rounds {
+		st.rounds++
+		st.turns += r.Turns
+		st.plays += r.Plays
+		st.markers += r.Markers
+		if r.Blocked {
+			st.blocked++
+		}
+
+		for id, pips := range r.Pips {
+			st.pips = append(st.pips, pips)
+			st.kindPips[kinds[id]] += pips
+			st.kindSeats[kinds[id]]++
+		}
+	}
+}
+
+func (st *stats) report(out io.Writer) {
+	if st.games == 0 || st.rounds == 0 {
+		fmt.Fprintln(out, "no games played")
+		return
+	}
+
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	defer w.Flush()
+
+	rounds := float64(st.rounds)
+	fmt.Fprintf(w, "games\t%d\n", st.games)
+	fmt.Fprintf(w, "rounds\t%d\n", st.rounds)
+	fmt.Fprintf(w, "turns per round\t%.1f\n", float64(st.turns)/rounds)
+	fmt.Fprintf(w, "tiles played per round\t%.1f\n", float64(st.plays)/rounds)
+	fmt.Fprintf(w, "markers per round\t%.2f\n", float64(st.markers)/rounds)
+	fmt.Fprintf(w, "turns putting up a marker\t%.1f%%\n", 100*float64(st.markers)/float64(st.turns))
+	fmt.Fprintf(w, "blocked rounds\t%.1f%%\n", 100*float64(st.blocked)/rounds)
+
+	fmt.Fprintf(w, "\nseat\twin rate\n")
+	for i, wins := range st.seatWins {
+		fmt.Fprintf(w, "seat%d\t%.1f%%\n", i+1, 100*wins/float64(st.games))
+	}
+
+	var kinds []string
+	for kind := range st.kindGames {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+
+	fmt.Fprintf(w, "\nbot\twin rate\tpips left per round\n")
+	for _, kind := range kinds {
+		fmt.Fprintf(w, "%s\t%.1f%%\t%.1f\n", kind,
+			100*st.kindWins[kind]/float64(st.kindGames[kind]),
+			float64(st.kindPips[kind])/float64(st.kindSeats[kind]))
+	}
+
+	sort.Ints(st.pips)
+	at := func(q float64) int {
+		return st.pips[int(q*float64(len(st.pips)-1))]
+	}
+
+	fmt.Fprintf(w, "\npips left in hand at the end of a round\n")
+	fmt.Fprintf(w, "min\t%d\n", st.pips[0])
+	fmt.Fprintf(w, "median\t%d\n", at(0.5))
+	fmt.Fprintf(w, "90th percentile\t%d\n", at(0.9))
+	fmt.Fprintf(w, "max\t%d\n", st.pips[len(st.pips)-1])
+
+	const bucket = 20
+	counts := map[int]int{}
+	for _, p := range st.pips {
+		counts[p/bucket]++
+	}
+	for b := 0; b <= st.pips[len(st.pips)-1]/bucket; b++ {
+		share := float64(counts[b]) / float64(len(st.pips))
+		fmt.Fprintf(w, "%d-%d\t%5.1f%%\t%s\n", b*bucket, b*bucket+bucket-1, 100*share, strings.Repeat("#", int(share*50+0.5)))
+	}
+}
@@ -2,25 +2,76 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"errors"
+	"flag"
 	"fmt"
 	"log"
+	"math/rand"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/cetacean/magiism/dominos"
+	"github.com/cetacean/magiism/dominos/ai"
 	"github.com/cetacean/magiism/dominos/game"
 )
 
+var (
+	position = flag.String("position", "", "position code to resume a game from")
+	players  = flag.String("players", "Xena,Vic", "comma-separated list of players")
+	style    = flag.String("style", "text", "how to draw tiles: text, unicode or ansi")
+	bots     = flag.String("bots", "", "comma-separated list of seats played by bots, as player=kind (kinds: "+strings.Join(ai.Names(), ", ")+", or "+ai.ExecPrefix+"command for an engine)")
+)
+
+// renderer draws tiles in the chosen style.
+var renderer dominos.Renderer = dominos.Text{}
+
 func main() {
-	gg, err := game.New([]string{"Xena", "Vic"})
+	flag.Parse()
+
+	r, ok := dominos.Renderers[*style]
+	if !ok {
+		log.Fatalf("there is no %s style", *style)
+	}
+	renderer = r
+
+	gg, err := newGame()
 	if err != nil {
 		log.Fatal(err)
 	}
+	table, err := newTable()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer table.Close()
+
 	g := &wrapper{Game: gg}
 	log.Printf("%s is the starting player!", g.GetActivePlayer().ID)
 	for {
-		err := g.Menu()
+		resps, err := table.Play(context.Background(), g.Game)
+		for _, r := range resps {
+			log.Printf("BOT %s: %s", r.PlayerID, strings.TrimSpace(r.GlobalMessage))
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		switch g.Phase {
+		case game.RoundOver:
+			log.Printf("ROUND %d OF %d IS OVER, SCORES: %v", g.Round, g.Rounds, g.Scores)
+			err := g.NextRound()
+			if err != nil {
+				log.Fatal(err)
+			}
+			continue
+		case game.MatchOver:
+			log.Printf("MATCH IS OVER, FINAL SCORES: %v", g.Scores)
+			return
+		}
+
+		err = g.Menu()
 		if err != nil {
 			switch err {
 			case game.ErrEndOfTurn:
@@ -32,6 +83,64 @@ func main() {
 	}
 }
 
+func newGame() (*game.Game, error) {
+	if *position == "" {
+		return game.New(strings.Split(*players, ","))
+	}
+
+	dg, err := dominos.ParseCode(*position)
+	if err != nil {
+		return nil, err
+	}
+
+	// A position doesn't say which set it was dealt from, so it is worked
+	// out from the tiles, and the match goes on for as many rounds.
+	rules := game.Standard
+	rules.Set = game.HighestDouble(dg)
+	err = rules.CheckSet()
+	if err != nil {
+		return nil, err
+	}
+
+	g := &game.Game{
+		Game:   dg,
+		Phase:  game.Playing,
+		Rules:  rules,
+		Round:  1,
+		Rounds: rules.Set + 1,
+		Scores: map[string]int{},
+	}
+
+	return g, nil
+}
+
+func newTable() (*ai.Table, error) {
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	t := &ai.Table{
+		Seats: map[string]ai.Player{},
+	}
+
+	if *bots == "" {
+		return t, nil
+	}
+
+	for _, seat := range strings.Split(*bots, ",") {
+		parts := strings.SplitN(seat, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("bot seats look like player=kind, not %q", seat)
+		}
+
+		bot, err := ai.New(parts[1], r)
+		if err != nil {
+			return nil, err
+		}
+
+		t.Seats[parts[0]] = bot
+	}
+
+	return t, nil
+}
+
 type wrapper struct {
 	*game.Game
 }
@@ -53,19 +162,27 @@ func (g *wrapper) Menu() error {
 
 	p := g.GetActivePlayer()
 
-	log.Printf("%s IS NOW UP", p.ID)
-	log.Printf("CENTER PIECE: (%d, %d)\n", g.Center.Left, g.Center.Right)
+	log.Printf("%s IS NOW UP (%s)", p.ID, g.Phase)
+	log.Printf("CENTER PIECE: %s", renderer.Tile(g.Center))
 	for i, e := range g.Trains {
-		log.Printf("%d: %s", i, e.Display())
+		log.Printf("%d: %s", i, dominos.RenderPath(renderer, e))
 	}
 
-	log.Println(g.GetActivePlayer().Display())
-	log.Printf("Commands: (p)lace | (b)ig turn | (k)nock | (d)raw | (e)ndturn")
+	log.Println("YOUR HAND: " + dominos.RenderHand(renderer, g.GetActivePlayer().Hand))
+	if v, err := g.View(p.ID); err == nil && v.Tracker != nil {
+		var counts []string
+		for _, pc := range v.Tracker {
+			counts = append(counts, fmt.Sprintf("%d:%d", pc.Pip, pc.Unseen))
+		}
+		log.Printf("UNSEEN BY PIP: %s", strings.Join(counts, " "))
+	}
+	log.Printf("POSITION: %s", g.Code())
+	log.Printf("Commands: (p)lace | (b)ig turn | (k)nock | (d)raw | (e)ndturn | (h)int | (l)eave")
 	scanner := bufio.NewScanner(os.Stdin)
 	fmt.Print("> ")
 
 	for scanner.Scan() {
-		log.Println(g.GetActivePlayer().Display())
+		log.Println("YOUR HAND: " + dominos.RenderHand(renderer, g.GetActivePlayer().Hand))
 		if scanner.Err() != nil {
 			log.Println(scanner.Err())
 			return scanner.Err()
@@ -100,6 +217,21 @@ func (g *wrapper) Menu() error {
 			ev.Action = game.DrawDomino
 		case "e":
 			ev.Action = game.EndTurn
+		case "h":
+			ev.Action = game.Hint
+		case "l":
+			fmt.Printf("(r)eturn tiles | (b)ot takes over | (k)eep seat> ")
+			scanner.Scan()
+
+			ev.Action = game.Forfeit
+			switch scanner.Text() {
+			case "b":
+				ev.Departure = game.HandToBot
+			case "k":
+				ev.Departure = game.KeepSeat
+			default:
+				ev.Departure = game.ReturnTiles
+			}
 		}
 
 		resp, err := g.HandleEvent(ev)
@@ -119,11 +251,18 @@ func (g *wrapper) Menu() error {
 			case dominos.ErrDanglingDouble:
 				log.Println("There is a dangling double that must be resolved")
 
+			case game.ErrHintsDisabled, game.ErrNoHintsLeft:
+				log.Println(err)
+
 			default:
 				return err
 			}
 		}
 
+		if err == nil && resp.UserMessage != "" {
+			log.Println("user: ", resp.UserMessage)
+		}
+
 		fmt.Print("> ")
 	}
 
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cetacean/magiism/dominos"
+	"github.com/cetacean/magiism/dominos/game"
+)
+
+// board describes what everyone can see of a game, with the tiles drawn by
+// r.
+func board(g *game.Game, r dominos.Renderer) string {
+	lines := []string{fmt.Sprintf("Round %d of %d, %s in the center, %d %s in the boneyard",
+		g.Round, g.Rounds, r.Tile(g.Center), len(g.TilePool), plural(len(g.TilePool), "tile", "tiles"))}
+
+	for i, p := range g.Trains {
+		owner := "Mexican train"
+		if !p.MexicanTrain {
+			owner = name(p.Player)
+			if pl, ok := g.GetPlayerByID(p.Player); ok {
+				owner += fmt.Sprintf(" (%d %s)", len(pl.Hand), plural(len(pl.Hand), "tile", "tiles"))
+				if pl.Knocked {
+					owner += " knocked"
+				}
+			}
+		}
+
+		line := fmt.Sprintf("`%d` %s:", i, owner)
+		for _, el := range p.Elements {
+			line += " " + dominos.RenderElement(r, el)
+		}
+		if p.Train && !p.MexicanTrain {
+			line += " - train is up"
+		}
+		if p.UnresolvedDouble {
+			line += " - double to cover!"
+		}
+		if p.Deserted {
+			line += " - left"
+		}
+
+		lines = append(lines, line)
+	}
+
+	if g.Phase == game.BigTurn || g.Phase == game.Playing {
+		lines = append(lines, fmt.Sprintf("It's %s's turn.", name(g.GetActivePlayer().ID)))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// hand describes a player's hand, numbered the way play takes them, and what
+// they can do with it, with the tiles drawn by r.
+func hand(v *game.View, r dominos.Renderer) string {
+	var tiles []string
+	for i, d := range v.Hand {
+		tiles = append(tiles, fmt.Sprintf("`%d` %s", i, r.Tile(d)))
+	}
+	lines := []string{"Your hand: " + strings.Join(tiles, " ")}
+
+	if !v.IsTurn() {
+		return lines[0]
+	}
+
+	var plays []string
+	for _, m := range v.Moves {
+		if m.Action == game.PlayDomino {
+			plays = append(plays, fmt.Sprintf("%s on `%d`", r.Tile(v.Hand[m.HandIndex]), m.PathID))
+		}
+	}
+
+	if len(plays) > 0 {
+		lines = append(lines, "It's your turn, you can play "+strings.Join(plays, ", "))
+	} else {
+		lines = append(lines, "It's your turn, but you have nothing to play")
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// plural picks the word that goes with n.
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+
+	return many
+}
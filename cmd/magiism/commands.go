@@ -0,0 +1,629 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cetacean/magiism/dominos"
+	"github.com/cetacean/magiism/dominos/ai"
+	"github.com/cetacean/magiism/dominos/game"
+	"github.com/cetacean/magiism/dominos/lobby"
+	"github.com/cetacean/magiism/dominos/picture"
+)
+
+// Command errors
+var (
+	errNoGame      = errors.New("there is no game in this channel")
+	errNoLobby     = errors.New("there is no lobby open in this channel")
+	errGameRunning = errors.New("there is already a game going on in this channel")
+	errLobbyOpen   = errors.New("there is already a lobby open in this channel")
+	errNotPlaying  = errors.New("you are not playing in this game")
+	errUnknownRule = errors.New("there are no rules by that name")
+	errNoSuchTile  = errors.New("you don't have that tile")
+	errNoSuchPath  = errors.New("there is no such path")
+	errUsage       = errors.New("that's not how that command works, see help")
+)
+
+// game loads the game being played in the channel.
+func (b *bot) game(channelID string) (*game.Game, error) {
+	g, err := b.store.GetGame(channelID)
+	if err == game.ErrGameNotFound {
+		return nil, errNoGame
+	}
+
+	return g, err
+}
+
+// lobby returns the lobby open in the channel.
+func (b *bot) lobby(channelID string) (*lobby.Lobby, error) {
+	l := b.channel(channelID).lobby
+	if l == nil || l.Closed {
+		return nil, errNoLobby
+	}
+
+	return l, nil
+}
+
+func (b *bot) newLobby(c *call) error {
+	if _, err := b.game(c.ChannelID); err != errNoGame {
+		if err != nil {
+			return err
+		}
+		return errGameRunning
+	}
+	if _, err := b.lobby(c.ChannelID); err == nil {
+		return errLobbyOpen
+	}
+
+	rules := game.Standard
+	if len(c.Args) > 1 {
+		var ok bool
+		rules, ok = game.RuleSets[strings.ToLower(c.Args[1])]
+		if !ok {
+			return errUnknownRule
+		}
+	}
+
+	l := lobby.New(c.AuthorID)
+	l.Rules = rules
+	l.SetReady(c.AuthorID, true)
+	b.channel(c.ChannelID).lobby = l
+
+	c.say("%s opened a lobby for a game with the %s rules. Join with `%s join`, then the host starts it with `%s start`.",
+		name(c.AuthorID), rules.Name, b.prefix, b.prefix)
+	return nil
+}
+
+func (b *bot) join(c *call) error {
+	l, err := b.lobby(c.ChannelID)
+	if err != nil {
+		return err
+	}
+
+	err = l.Join(c.AuthorID)
+	if err != nil {
+		return err
+	}
+	l.SetReady(c.AuthorID, true)
+
+	c.say("%s joined, that makes %d players", name(c.AuthorID), len(l.Players))
+	return nil
+}
+
+func (b *bot) leave(c *call) error {
+	l, err := b.lobby(c.ChannelID)
+	if err != nil {
+		return err
+	}
+
+	err = l.Leave(c.AuthorID)
+	if err != nil {
+		return err
+	}
+
+	// Bots don't keep a lobby open on their own.
+	humans := 0
+	for _, id := range l.Players {
+		if !strings.HasPrefix(id, botPrefix) {
+			humans++
+		}
+	}
+	if humans == 0 {
+		b.channel(c.ChannelID).lobby = nil
+		c.say("%s left and the lobby closed", name(c.AuthorID))
+		return nil
+	}
+
+	if strings.HasPrefix(l.Host, botPrefix) {
+		for _, id := range l.Players {
+			if !strings.HasPrefix(id, botPrefix) {
+				l.Host = id
+				break
+			}
+		}
+	}
+
+	c.say("%s left, %s is the host", name(c.AuthorID), name(l.Host))
+	return nil
+}
+
+// bots lists the kinds of bots that can be seated in the channel: the ones
+// built in and the guild's remote bots.
+func (b *bot) bots(channelID string) []string {
+	kinds := ai.Names()
+
+	guild, err := b.guild(channelID)
+	if err == nil {
+		kinds = append(kinds, b.registry.Names(guild)...)
+	}
+
+	return kinds
+}
+
+// player creates the bot playing for a player seated by addbot.
+func (b *bot) player(channelID, id string) (ai.Player, error) {
+	kind := strings.TrimPrefix(id, botPrefix)
+	if i := strings.Index(kind, "#"); i >= 0 {
+		kind = kind[:i]
+	}
+
+	for _, builtin := range ai.Names() {
+		if kind == builtin {
+			return ai.New(kind, nil)
+		}
+	}
+
+	guild, err := b.guild(channelID)
+	if err != nil {
+		return nil, err
+	}
+
+	return b.registry.Bot(guild, kind)
+}
+
+func (b *bot) addBot(c *call) error {
+	l, err := b.lobby(c.ChannelID)
+	if err != nil {
+		return err
+	}
+	if len(c.Args) != 2 {
+		c.reply("Bots you can add: %s", strings.Join(b.bots(c.ChannelID), ", "))
+		return nil
+	}
+	if l.Host != c.AuthorID {
+		return lobby.ErrNotHost
+	}
+
+	kind := strings.ToLower(c.Args[1])
+	id := botPrefix + kind
+	if _, err := b.player(c.ChannelID, id); err != nil {
+		return err
+	}
+
+	for n := 2; l.Join(id) == lobby.ErrAlreadyJoined; n++ {
+		id = fmt.Sprintf("%s%s#%d", botPrefix, kind, n)
+	}
+	if err := l.SetReady(id, true); err != nil {
+		return err
+	}
+
+	c.say("%s sat down, that makes %d players", name(id), len(l.Players))
+	return nil
+}
+
+func (b *bot) start(c *call) error {
+	l, err := b.lobby(c.ChannelID)
+	if err != nil {
+		return err
+	}
+
+	g, err := l.Start(c.AuthorID)
+	if err != nil {
+		return err
+	}
+	b.channel(c.ChannelID).lobby = nil
+
+	var seats []string
+	for _, p := range g.Players {
+		seats = append(seats, name(p.ID))
+	}
+	c.say("The game is on! Round 1 of %d, with %s in the center. Seats: %s",
+		g.Rounds, b.renderer(c.ChannelID, "").Tile(g.Center), strings.Join(seats, ", "))
+
+	return b.advance(c, g, len(g.Log), "")
+}
+
+// event makes a move for whoever sent the command.
+func (b *bot) event(c *call, e game.Event) error {
+	g, err := b.game(c.ChannelID)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := g.GetPlayerByID(c.AuthorID); !ok {
+		return errNotPlaying
+	}
+
+	before, active := len(g.Log), g.GetActivePlayer().ID
+	e.PlayerID = c.AuthorID
+
+	r, err := g.HandleEvent(&e)
+	if err != nil && err != game.ErrEndOfTurn {
+		return err
+	}
+
+	err = b.advance(c, g, before, active)
+
+	// Said last, since it is about what was just logged.
+	if r != nil && r.UserMessage != "" {
+		msg := strings.Replace(r.UserMessage, "$EVENT_PLAYER_NAME", name(c.AuthorID), -1)
+		c.tell(c.AuthorID, "%s", strings.TrimSpace(names(g, msg)))
+	}
+
+	return err
+}
+
+// table seats the bots of a game.
+func (b *bot) table(channelID string, g *game.Game) *ai.Table {
+	t := &ai.Table{Seats: map[string]ai.Player{}}
+	for _, p := range g.Players {
+		if !strings.HasPrefix(p.ID, botPrefix) {
+			continue
+		}
+
+		bot, err := b.player(channelID, p.ID)
+		if err != nil {
+			// Someone else plays it rather than the game getting stuck.
+			log.Printf("%s in %s: %v", p.ID, channelID, err)
+			bot = ai.Greedy{}
+		}
+		t.Seats[p.ID] = bot
+	}
+
+	return t
+}
+
+// advance lets the bots play after something happened in a game, deals the
+// next round when one is over and saves the game. Everything logged since
+// before goes into the activity under the board, and the channel is told
+// whose turn it is if it isn't active's anymore.
+func (b *bot) advance(c *call, g *game.Game, before int, active string) error {
+	t := b.table(c.ChannelID, g)
+
+	var lines []string
+	logged := func() {
+		for _, e := range g.Log[before:] {
+			lines = append(lines, names(g, e.Text))
+		}
+		before = len(g.Log)
+	}
+
+	for {
+		_, err := t.Play(context.Background(), g)
+		if err != nil {
+			log.Printf("bots in %s: %v", c.ChannelID, err)
+		}
+
+		if g.Phase != game.RoundOver {
+			break
+		}
+
+		logged()
+		c.say("Round %d is over. Scores: %s", g.Round, scores(g))
+
+		err = g.NextRound()
+		if err != nil {
+			return err
+		}
+		if g.Phase == game.MatchOver {
+			break
+		}
+		active = ""
+	}
+
+	logged()
+	b.updateBoard(c, g, lines)
+	b.updateHands(c, g)
+
+	if g.Phase == game.MatchOver {
+		if ids := ranked(g); len(ids) > 0 {
+			c.say("The match is over! %s wins.", name(ids[0]))
+		}
+		return b.store.DeleteGame(c.ChannelID)
+	}
+
+	if id := g.GetActivePlayer().ID; id != active {
+		c.say("It's %s's turn.", name(id))
+	}
+
+	return b.store.PutGame(c.ChannelID, g)
+}
+
+// ranked lists the players with a score, best first. Players who forfeited
+// and returned their tiles keep scoring nothing, so they are left out.
+func ranked(g *game.Game) []string {
+	var ids []string
+	for id := range g.Scores {
+		if g.SeatOf(id) != game.Gone {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return g.Scores[ids[i]] < g.Scores[ids[j]] })
+
+	return ids
+}
+
+// forfeited lists the players who left and returned their tiles.
+func forfeited(g *game.Game) []string {
+	var ids []string
+	for _, p := range g.Players {
+		if g.SeatOf(p.ID) == game.Gone {
+			ids = append(ids, name(p.ID))
+		}
+	}
+
+	return ids
+}
+
+// scores lists everyone's score, best first.
+func scores(g *game.Game) string {
+	var result []string
+	for _, id := range ranked(g) {
+		result = append(result, fmt.Sprintf("%s %d", name(id), g.Scores[id]))
+	}
+
+	return strings.Join(result, ", ")
+}
+
+// tile finds a tile in a hand, by its index or by its sides such as 6|4.
+func tile(hand []dominos.Domino, s string) (int, error) {
+	s = strings.Trim(s, "[]")
+	if i, err := strconv.Atoi(s); err == nil {
+		if i < 0 || i >= len(hand) {
+			return 0, errNoSuchTile
+		}
+		return i, nil
+	}
+
+	sides := strings.FieldsFunc(s, func(r rune) bool { return r == '|' || r == '-' || r == ':' })
+	if len(sides) != 2 {
+		return 0, errUsage
+	}
+
+	l, err1 := strconv.Atoi(sides[0])
+	r, err2 := strconv.Atoi(sides[1])
+	if err1 != nil || err2 != nil {
+		return 0, errUsage
+	}
+
+	for i, d := range hand {
+		if (d.Left == l && d.Right == r) || (d.Left == r && d.Right == l) {
+			return i, nil
+		}
+	}
+
+	return 0, errNoSuchTile
+}
+
+// path finds a path by its index, or m for the Mexican train.
+func path(g *game.Game, s string) (int, error) {
+	s = strings.ToLower(s)
+	if s == "m" || s == "mexican" {
+		for i, p := range g.Trains {
+			if p.MexicanTrain {
+				return i, nil
+			}
+		}
+		return 0, errNoSuchPath
+	}
+
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, errUsage
+	}
+	if i < 0 || i >= len(g.Trains) {
+		return 0, errNoSuchPath
+	}
+
+	return i, nil
+}
+
+func (b *bot) play(c *call) error {
+	if len(c.Args) != 3 {
+		return errUsage
+	}
+
+	g, err := b.game(c.ChannelID)
+	if err != nil {
+		return err
+	}
+
+	p, ok := g.GetPlayerByID(c.AuthorID)
+	if !ok {
+		return errNotPlaying
+	}
+
+	hi, err := tile(p.Hand, c.Args[1])
+	if err != nil {
+		return err
+	}
+
+	pi, err := path(g, c.Args[2])
+	if err != nil {
+		return err
+	}
+
+	return b.event(c, game.Event{Action: game.PlayDomino, HandIndex: hi, PathID: pi})
+}
+
+func (b *bot) draw(c *call) error {
+	return b.event(c, game.Event{Action: game.DrawDomino})
+}
+
+func (b *bot) knock(c *call) error {
+	return b.event(c, game.Event{Action: game.Knock})
+}
+
+func (b *bot) endTurn(c *call) error {
+	return b.event(c, game.Event{Action: game.EndTurn})
+}
+
+func (b *bot) hint(c *call) error {
+	return b.event(c, game.Event{Action: game.Hint})
+}
+
+func (b *bot) forfeit(c *call) error {
+	how := game.ReturnTiles
+	if len(c.Args) > 1 {
+		switch strings.ToLower(c.Args[1]) {
+		case "bot":
+			how = game.HandToBot
+		case "keep":
+			how = game.KeepSeat
+		default:
+			return errUsage
+		}
+	}
+
+	return b.event(c, game.Event{Action: game.Forfeit, Departure: how})
+}
+
+func (b *bot) take(c *call) error {
+	if len(c.Args) != 2 {
+		return errUsage
+	}
+
+	g, err := b.game(c.ChannelID)
+	if err != nil {
+		return err
+	}
+
+	seat := strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(c.Args[1], "<@"), "!"), ">")
+
+	before, active := len(g.Log), g.GetActivePlayer().ID
+	err = g.TakeSeat(seat, c.AuthorID)
+	if err != nil {
+		return err
+	}
+
+	return b.advance(c, g, before, active)
+}
+
+func (b *bot) board(c *call) error {
+	g, err := b.game(c.ChannelID)
+	if err != nil {
+		return err
+	}
+
+	// In a DM, the board is only for its author to see.
+	viewer := ""
+	if c.Private {
+		viewer = c.AuthorID
+	}
+	text := board(g, b.renderer(c.ChannelID, viewer))
+
+	if len(c.Args) > 1 && strings.ToLower(c.Args[1]) == "text" {
+		c.reply("%s", text)
+		return nil
+	}
+
+	data, err := picture.PNG(g.Game)
+	if err != nil {
+		log.Printf("can't draw the board in %s: %v", c.ChannelID, err)
+		c.reply("%s", text)
+		return nil
+	}
+
+	c.attach("board.png", data, text)
+	return nil
+}
+
+func (b *bot) hand(c *call) error {
+	g, err := b.game(c.ChannelID)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := g.GetPlayerByID(c.AuthorID); !ok {
+		return errNotPlaying
+	}
+
+	// Start over with a new message at the bottom of their DMs.
+	ch := b.channel(c.ChannelID)
+	delete(ch.hands, c.AuthorID)
+	delete(ch.blocked, c.AuthorID)
+	b.updateHands(c, g)
+
+	if !c.Private && !ch.blocked[c.AuthorID] {
+		c.say("%s, I sent you your hand", name(c.AuthorID))
+	}
+	return nil
+}
+
+func (b *bot) help(c *call) error {
+	c.reply("Commands, each starting with `%s` or a mention of me, or just the command in a DM:", b.prefix)
+	for _, cmd := range commands {
+		usage := cmd.Name
+		if cmd.Args != "" {
+			usage += " " + cmd.Args
+		}
+		c.reply("`%s` - %s", usage, cmd.Help)
+	}
+
+	return nil
+}
+
+// tick checks the clocks of every game and lets the channels know what
+// came of it.
+func (b *bot) tick() {
+	ids, err := b.store.Games()
+	if err != nil {
+		log.Printf("can't list games: %v", err)
+		return
+	}
+
+	for _, id := range ids {
+		ch := b.channel(id)
+
+		// A game busy with slow bots is left for the next tick, rather
+		// than ticks piling up behind it.
+		b.mu.Lock()
+		busy := ch.ticking
+		ch.ticking = true
+		b.mu.Unlock()
+		if busy {
+			continue
+		}
+
+		go func(id string) {
+			ch.mu.Lock()
+			b.tickGame(id)
+			ch.mu.Unlock()
+
+			b.mu.Lock()
+			ch.ticking = false
+			b.mu.Unlock()
+		}(id)
+	}
+}
+
+// tickGame runs the clock of the game in a channel. The caller has to hold
+// the channel's lock.
+func (b *bot) tickGame(id string) {
+	g, err := b.store.GetGame(id)
+	if err == game.ErrGameNotFound {
+		return
+	}
+	if err != nil {
+		log.Printf("can't load %s: %v", id, err)
+		return
+	}
+
+	before, active := len(g.Log), g.GetActivePlayer().ID
+	resps, err := g.Tick()
+	if err != nil {
+		log.Printf("ticking %s: %v", id, err)
+		return
+	}
+	if len(resps) == 0 {
+		return
+	}
+
+	c := &call{ChannelID: id}
+	for _, r := range resps {
+		if r.UserMessage != "" {
+			c.tell(r.PlayerID, "%s", names(g, r.UserMessage))
+		}
+	}
+
+	err = b.advance(c, g, before, active)
+	if err != nil {
+		log.Printf("ticking %s: %v", id, err)
+	}
+	b.send(c)
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/cetacean/magiism/dominos"
+	"github.com/cetacean/magiism/dominos/picture"
+)
+
+var (
+	errNoGuild     = errors.New("emoji can only be added to a server")
+	errNoEmojiPerm = errors.New("only members who may manage emoji can add them")
+)
+
+// Permissions discordgo doesn't know of yet.
+const (
+	permissionAdministrator = 1 << 3
+	permissionManageEmojis  = 1 << 30
+)
+
+// guildEmojis picks the pip emoji out of the custom emoji of a guild.
+func guildEmojis(all []*discordgo.Emoji) dominos.Emojis {
+	names := map[string]int{}
+	for n := 0; n <= picture.MaxPips; n++ {
+		names[dominos.EmojiName(n)] = n
+	}
+
+	e := dominos.Emojis{}
+	for _, em := range all {
+		if n, ok := names[em.Name]; ok {
+			e[n] = "<:" + em.Name + ":" + em.ID + ">"
+		}
+	}
+
+	return e
+}
+
+// emojisOf returns the pip emoji of the guild a channel is in, looking them
+// up the first time they are needed. DMs have none.
+func (b *bot) emojisOf(channelID string) dominos.Emojis {
+	guild, err := b.guild(channelID)
+	if err != nil || guild == "" {
+		return nil
+	}
+
+	b.mu.Lock()
+	e, ok := b.emoji[guild]
+	b.mu.Unlock()
+	if ok {
+		return e
+	}
+
+	g, err := b.chat.Guild(guild)
+	if err != nil {
+		log.Printf("can't look up the emoji of %s: %v", guild, err)
+		return nil
+	}
+
+	return b.setEmojis(guild, guildEmojis(g.Emojis))
+}
+
+// setEmojis remembers the pip emoji of a guild, and returns them.
+func (b *bot) setEmojis(guild string, e dominos.Emojis) dominos.Emojis {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.emoji[guild] = e
+	return e
+}
+
+// guildCreate is called by discordgo for every guild the bot is in when it
+// connects, and for every guild it joins later. It checks which pip emoji
+// the guild is missing, so that whoever runs the bot can add them.
+func (b *bot) guildCreate(s *discordgo.Session, m *discordgo.GuildCreate) {
+	e := b.setEmojis(m.ID, guildEmojis(m.Emojis))
+
+	missing := e.Missing(picture.MaxPips)
+	if len(missing) == 0 {
+		return
+	}
+
+	var names []string
+	for _, n := range missing {
+		names = append(names, dominos.EmojiName(n))
+	}
+	log.Printf("%s (%s) is missing the emoji %s, add them with %s emojis",
+		m.Name, m.ID, strings.Join(names, ", "), b.prefix)
+}
+
+// guildEmojisUpdate is called by discordgo when a guild's emoji change.
+func (b *bot) guildEmojisUpdate(s *discordgo.Session, m *discordgo.GuildEmojisUpdate) {
+	b.setEmojis(m.GuildID, guildEmojis(m.Emojis))
+}
+
+// emojiUpload is the body of a request to add a custom emoji to a guild.
+type emojiUpload struct {
+	Name  string `json:"name"`
+	Image string `json:"image"` // A data URI.
+}
+
+// uploadEmoji adds the custom emoji for a face showing n to a guild.
+// discordgo has no call for it, so the request is made by hand.
+func (b *bot) uploadEmoji(guildID string, n int) (*discordgo.Emoji, error) {
+	data, err := picture.FacePNG(n, picture.EmojiSize)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := b.chat.Request("POST", discordgo.EndpointGuild(guildID)+"/emojis", emojiUpload{
+		Name:  dominos.EmojiName(n),
+		Image: "data:image/png;base64," + base64.StdEncoding.EncodeToString(data),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	em := &discordgo.Emoji{}
+	return em, json.Unmarshal(resp, em)
+}
+
+func (b *bot) emojis(c *call) error {
+	guild, err := b.guild(c.ChannelID)
+	if err != nil {
+		return err
+	}
+	if guild == "" {
+		return errNoGuild
+	}
+
+	// discordgo gives the owner, and anyone who could grant themselves the
+	// rest by managing roles, every permission it knows of.
+	perms, err := b.chat.UserChannelPermissions(c.AuthorID, c.ChannelID)
+	if err != nil {
+		return err
+	}
+	if perms&(permissionAdministrator|permissionManageEmojis) == 0 &&
+		perms&discordgo.PermissionAll != discordgo.PermissionAll {
+		return errNoEmojiPerm
+	}
+
+	// Look again, someone may have added or removed some by hand.
+	g, err := b.chat.Guild(guild)
+	if err != nil {
+		return err
+	}
+	e := guildEmojis(g.Emojis)
+	b.setEmojis(guild, e)
+
+	missing := e.Missing(picture.MaxPips)
+	if len(missing) == 0 {
+		c.reply("This server already has every pip emoji")
+		return nil
+	}
+
+	// The emoji the guild had are shared with other channels by now, so
+	// the ones added go in a copy.
+	all := dominos.Emojis{}
+	for n, em := range e {
+		all[n] = em
+	}
+
+	var added []string
+	for _, n := range missing {
+		var em *discordgo.Emoji
+		em, err = b.uploadEmoji(guild, n)
+		if err != nil {
+			break
+		}
+
+		all[n] = "<:" + em.Name + ":" + em.ID + ">"
+		added = append(added, all[n])
+	}
+	b.setEmojis(guild, all)
+
+	if len(added) > 0 {
+		c.reply("Added %s", strings.Join(added, " "))
+	}
+	if err != nil {
+		// Usually the bot may not manage emoji, or the server is out of slots.
+		return fmt.Errorf("can't add the rest of the emoji: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/cetacean/magiism/dominos/game"
+)
+
+// Possible messages about hands.
+const (
+	blockedMsg  = "%s, I can't send you your hand. Allow direct messages from server members, then use `%s hand`."
+	gameOverMsg = "The game in <#%s> is over."
+)
+
+// handMsg is the messages in a player's DMs that show their hand. A big hand
+// with all its plays can take more than one.
+type handMsg struct {
+	ChannelID  string
+	MessageIDs []string
+	Text       string // What it says, so unchanged hands aren't edited.
+}
+
+// updateHands brings the hand message of every human player in the game up
+// to date, sending one to those who don't have one yet. Players whose DMs
+// are closed are told so in the channel, once.
+func (b *bot) updateHands(c *call, g *game.Game) {
+	ch := b.channel(c.ChannelID)
+	for _, p := range g.Players {
+		if strings.HasPrefix(p.ID, botPrefix) {
+			continue
+		}
+
+		if ch.blocked[p.ID] {
+			continue
+		}
+
+		var text string
+		switch g.Phase {
+		case game.BigTurn, game.Playing:
+			if g.SeatOf(p.ID) != game.Human {
+				continue
+			}
+
+			v, err := g.View(p.ID)
+			if err != nil {
+				log.Printf("can't show %s their hand: %v", p.ID, err)
+				continue
+			}
+			text = hand(v, b.renderer(c.ChannelID, p.ID))
+			b.setPlaying(p.ID, c.ChannelID)
+
+		case game.MatchOver:
+			text = fmt.Sprintf(gameOverMsg, c.ChannelID)
+
+		default:
+			continue
+		}
+
+		err := b.showHand(ch, p.ID, text)
+		if err != nil {
+			log.Printf("can't DM %s: %v", p.ID, err)
+			ch.blocked[p.ID] = true
+			c.say(blockedMsg, name(p.ID), b.prefix)
+		}
+
+		if g.Phase == game.MatchOver {
+			delete(ch.hands, p.ID)
+			delete(ch.blocked, p.ID)
+		}
+	}
+}
+
+// showHand makes the player's hand messages say text, editing the ones they
+// have where it can. The caller has to hold the lock of the player's game's
+// channel.
+func (b *bot) showHand(ch *channel, id, text string) error {
+	hm, ok := ch.hands[id]
+	if ok && hm.Text == text {
+		return nil
+	}
+
+	if !ok {
+		dm, err := b.dm(id)
+		if err != nil {
+			return err
+		}
+
+		hm = &handMsg{ChannelID: dm}
+	}
+
+	// Keep what got sent even if some pages didn't, so it gets edited.
+	ids, err := b.postPages(hm.ChannelID, hm.MessageIDs, paginate(text, maxMessage))
+	hm.MessageIDs = ids
+	ch.hands[id] = hm
+	if err != nil {
+		return err
+	}
+
+	hm.Text = text
+	return nil
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestHandsEditedInPlace(t *testing.T) {
+	b, chat := testBot(t)
+	seatBoard(t, b, "c", duel, 1)
+
+	run(t, b, chat, []step{
+		{ChannelID: "c", AuthorID: "alice", Content: "!mt play 6|1 0", Want: "It's <@bob>'s turn."},
+	})
+
+	hands := chat.inOrder("dm-alice")
+	if len(hands) != 1 || strings.Contains(hands[0], "6|1") {
+		t.Fatalf("wanted alice's hand without [6|1] in one message, got %q", hands)
+	}
+	edits := chat.edits
+
+	run(t, b, chat, []step{
+		{ChannelID: "c", AuthorID: "bob", Content: "!mt play 6|5 1", Want: "It's <@alice>'s turn."},
+		{ChannelID: "c", AuthorID: "alice", Content: "!mt play 1|2 0", Want: "It's <@bob>'s turn."},
+	})
+
+	got := chat.inOrder("dm-alice")
+	if len(got) != 1 || strings.Contains(got[0], "1|2") {
+		t.Fatalf("wanted alice's hand without [1|2] in one message, got %q", got)
+	}
+	if chat.edits == edits {
+		t.Fatal("alice's hand changed, but it wasn't edited")
+	}
+
+	// Asking for it again sends a new one at the bottom of the DMs.
+	run(t, b, chat, []step{
+		{ChannelID: "c", AuthorID: "alice", Content: "!mt hand", Want: "<@alice>, I sent you your hand"},
+	})
+	if got := chat.inOrder("dm-alice"); len(got) != 2 {
+		t.Fatalf("wanted a second hand message, got %q", got)
+	}
+}
+
+func TestHandsBlocked(t *testing.T) {
+	b, chat := testBot(t)
+	seatBoard(t, b, "c", duel, 1)
+	chat.closed["dm-bob"] = true
+
+	run(t, b, chat, []step{
+		{ChannelID: "c", AuthorID: "alice", Content: "!mt play 6|1 0"},
+		{ChannelID: "c", AuthorID: "bob", Content: "!mt play 6|5 1"},
+		{ChannelID: "c", AuthorID: "alice", Content: "!mt play 1|2 0"},
+	})
+
+	warning := fmt.Sprintf(blockedMsg, "<@bob>", "!mt")
+	warned := 0
+	for _, m := range chat.inOrder("c") {
+		warned += strings.Count(m, warning)
+	}
+	if warned != 1 {
+		t.Fatalf("wanted bob told about his DMs once, he was told %d times", warned)
+	}
+	if len(chat.inOrder("dm-alice")) != 1 {
+		t.Fatal("alice's hand went missing while bob's DMs were closed")
+	}
+
+	// Once they are open, asking for the hand sends it.
+	delete(chat.closed, "dm-bob")
+	run(t, b, chat, []step{
+		{ChannelID: "c", AuthorID: "bob", Content: "!mt hand", Want: "<@bob>, I sent you your hand"},
+	})
+	if len(chat.inOrder("dm-bob")) != 1 {
+		t.Fatal("bob's hand wasn't sent once his DMs were open")
+	}
+}
+
+func TestHandsMatchOver(t *testing.T) {
+	b, chat := testBot(t)
+	seatBoard(t, b, "c", lastMove, 7)
+
+	run(t, b, chat, []step{
+		{ChannelID: "c", AuthorID: "alice", Content: "!mt play 1|2 0", Want: "The match is over! <@alice> wins."},
+	})
+
+	over := fmt.Sprintf(gameOverMsg, "c")
+	for _, id := range []string{"alice", "bob"} {
+		if got := chat.inOrder("dm-" + id); len(got) != 1 || got[0] != over {
+			t.Errorf("%s's hand should say the game is over, got %q", id, got)
+		}
+	}
+	if ch := b.channel("c"); len(ch.hands) != 0 {
+		t.Fatalf("the hands of a finished game were kept: %v", ch.hands)
+	}
+}
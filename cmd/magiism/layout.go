@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxMessage is the longest message Discord takes, in characters.
+const maxMessage = 2000
+
+// ellipsis marks where shorten cut a line.
+const ellipsis = " …"
+
+// paginate splits text into pages of at most limit characters. Pages only
+// break between lines, so a train is never split over two messages, and
+// lines too long for a page of their own are shortened.
+func paginate(text string, limit int) []string {
+	var (
+		pages []string
+		page  []string
+		size  int
+	)
+
+	for _, line := range strings.Split(text, "\n") {
+		line = shorten(line, limit)
+		n := utf8.RuneCountInString(line)
+
+		if len(page) > 0 && size+1+n > limit {
+			pages = append(pages, strings.Join(page, "\n"))
+			page, size = nil, 0
+		}
+
+		if len(page) > 0 {
+			size++
+		}
+		page = append(page, line)
+		size += n
+	}
+
+	return append(pages, strings.Join(page, "\n"))
+}
+
+// shorten cuts a line down to at most limit characters. It keeps the start
+// of the line up to its first colon, which says what the line is about, and
+// as much of the end as fits, which for a train is where it is played on.
+// Custom emoji are never cut in half, since Discord would show the rest of
+// them as text.
+func shorten(line string, limit int) string {
+	r := []rune(line)
+	if len(r) <= limit {
+		return line
+	}
+
+	head := 0
+	for i, c := range r {
+		if c == ':' {
+			head = i + 1
+			break
+		}
+	}
+	if head > limit/2 {
+		head = limit / 2
+	}
+	if open := emojiAt(r, head); open >= 0 {
+		head = open
+	}
+
+	start := len(r) - (limit - head - utf8.RuneCountInString(ellipsis))
+
+	// Don't start halfway through a tile.
+	if i := runeIndex(r[start:], ' '); i >= 0 {
+		start += i
+	} else if emojiAt(r, start) >= 0 {
+		start += runeIndex(r[start:], '>') + 1
+	}
+
+	return string(r[:head]) + ellipsis + string(r[start:])
+}
+
+// emojiAt returns where the custom emoji that cutting r at i would cut in
+// half starts, such as <:d6:1234>, or -1 if the cut isn't inside one.
+func emojiAt(r []rune, i int) int {
+	for j := i - 1; j >= 0; j-- {
+		switch r[j] {
+		case '>':
+			return -1
+		case '<':
+			if j+1 < len(r) && r[j+1] == ':' && runeIndex(r[j:], '>') >= 0 {
+				return j
+			}
+			return -1
+		}
+	}
+
+	return -1
+}
+
+// runeIndex returns the index of the first c in r, or -1.
+func runeIndex(r []rune, c rune) int {
+	for i, x := range r {
+		if x == c {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// headed paginates text into pages that all start with header, numbered if
+// there is more than one, so that every page can be told apart.
+func headed(header, text string) []string {
+	// Leave room for the longest page numbers there could be.
+	room := maxMessage - utf8.RuneCountInString(header) - len(" (99/99)\n")
+
+	pages := paginate(text, room)
+	for i, page := range pages {
+		if len(pages) == 1 {
+			pages[i] = header + "\n" + page
+		} else {
+			pages[i] = fmt.Sprintf("%s (%d/%d)\n%s", header, i+1, len(pages), page)
+		}
+	}
+
+	return pages
+}
+
+// postPages makes the messages with the given IDs say pages, in order. It
+// edits the messages it has, sends new ones if there are more pages than
+// messages and deletes messages left over if there are fewer. It returns the
+// IDs of the messages that now hold the pages. If a page can't be sent, the
+// messages it didn't get to are left as they are and their IDs come after
+// those of the pages that were posted, so the next try can still use them.
+func (b *bot) postPages(channelID string, ids, pages []string) ([]string, error) {
+	var result []string
+	for i, page := range pages {
+		if i < len(ids) {
+			_, err := b.chat.ChannelMessageEdit(channelID, ids[i], page)
+			if err == nil {
+				result = append(result, ids[i])
+				continue
+			}
+		}
+
+		m, err := b.chat.ChannelMessageSend(channelID, page)
+		if err != nil {
+			if i+1 < len(ids) {
+				result = append(result, ids[i+1:]...)
+			}
+			return result, err
+		}
+		result = append(result, m.ID)
+	}
+
+	for i := len(pages); i < len(ids); i++ {
+		// It doesn't matter much if an old page sticks around.
+		b.chat.ChannelMessageDelete(channelID, ids[i])
+	}
+
+	return result, nil
+}
@@ -0,0 +1,360 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+var errFakeChat = errors.New("fake chat is down")
+
+// fakeChat keeps the messages of every channel in memory. Sends fail once
+// sendsLeft runs out, if it isn't negative, and always in closed channels.
+// Messages are sent by the bot, unless authors says otherwise.
+type fakeChat struct {
+	mu        sync.Mutex
+	messages  map[string]map[string]string // By channel, then message ID.
+	pins      map[string][]string          // Pinned message IDs, by channel.
+	authors   map[string]string            // By message ID.
+	closed    map[string]bool
+	lastID    int
+	sendsLeft int
+	edits     int
+	deletes   int
+}
+
+func newFakeChat() *fakeChat {
+	return &fakeChat{
+		messages:  map[string]map[string]string{},
+		pins:      map[string][]string{},
+		authors:   map[string]string{},
+		closed:    map[string]bool{},
+		sendsLeft: -1,
+	}
+}
+
+func (f *fakeChat) ChannelMessageSend(channelID, content string) (*discordgo.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.sendsLeft == 0 || f.closed[channelID] {
+		return nil, errFakeChat
+	}
+	f.sendsLeft--
+
+	if f.messages[channelID] == nil {
+		f.messages[channelID] = map[string]string{}
+	}
+
+	f.lastID++
+	id := fmt.Sprint(f.lastID)
+	f.messages[channelID][id] = content
+	return &discordgo.Message{ID: id, ChannelID: channelID, Content: content}, nil
+}
+
+func (f *fakeChat) ChannelMessageEdit(channelID, messageID, content string) (*discordgo.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.messages[channelID][messageID]; !ok {
+		return nil, errFakeChat
+	}
+
+	f.edits++
+	f.messages[channelID][messageID] = content
+	return &discordgo.Message{ID: messageID, ChannelID: channelID, Content: content}, nil
+}
+
+func (f *fakeChat) ChannelMessageDelete(channelID, messageID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.messages[channelID][messageID]; !ok {
+		return errFakeChat
+	}
+
+	f.deletes++
+	delete(f.messages[channelID], messageID)
+	return nil
+}
+
+func (f *fakeChat) ChannelFileSend(channelID, name string, r io.Reader) (*discordgo.Message, error) {
+	return f.ChannelMessageSend(channelID, name)
+}
+
+// inOrder returns what the messages of a channel say, oldest first.
+func (f *fakeChat) inOrder(channelID string) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var ids []int
+	for id := range f.messages[channelID] {
+		n, _ := strconv.Atoi(id)
+		ids = append(ids, n)
+	}
+	sort.Ints(ids)
+
+	var result []string
+	for _, id := range ids {
+		result = append(result, f.messages[channelID][strconv.Itoa(id)])
+	}
+
+	return result
+}
+
+// pinned returns the IDs of the messages pinned in a channel.
+func (f *fakeChat) pinned(channelID string) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]string(nil), f.pins[channelID]...)
+}
+
+func (f *fakeChat) ChannelMessagePin(channelID, messageID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.messages[channelID][messageID]; !ok {
+		return errFakeChat
+	}
+
+	f.pins[channelID] = append(f.pins[channelID], messageID)
+	return nil
+}
+
+func (f *fakeChat) ChannelMessageUnpin(channelID, messageID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for i, id := range f.pins[channelID] {
+		if id == messageID {
+			f.pins[channelID] = append(f.pins[channelID][:i], f.pins[channelID][i+1:]...)
+			return nil
+		}
+	}
+
+	return errFakeChat
+}
+
+// ChannelMessagesPinned returns the pins newest first, like Discord does.
+func (f *fakeChat) ChannelMessagesPinned(channelID string) ([]*discordgo.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var result []*discordgo.Message
+	for _, id := range f.pins[channelID] {
+		m := &discordgo.Message{ID: id, ChannelID: channelID, Content: f.messages[channelID][id]}
+		if author, ok := f.authors[id]; ok {
+			m.Author = &discordgo.User{ID: author}
+		}
+		result = append([]*discordgo.Message{m}, result...)
+	}
+
+	return result, nil
+}
+
+func (f *fakeChat) UserChannelCreate(recipientID string) (*discordgo.Channel, error) {
+	return &discordgo.Channel{ID: "dm-" + recipientID}, nil
+}
+
+func (f *fakeChat) Channel(channelID string) (*discordgo.Channel, error) {
+	if strings.HasPrefix(channelID, "dm-") {
+		return &discordgo.Channel{ID: channelID}, nil
+	}
+	return &discordgo.Channel{ID: channelID, GuildID: "guild"}, nil
+}
+
+func (f *fakeChat) Guild(guildID string) (*discordgo.Guild, error) {
+	return &discordgo.Guild{ID: guildID}, nil
+}
+
+func (f *fakeChat) UserChannelPermissions(userID, channelID string) (int, error) {
+	return 0, nil
+}
+
+func (f *fakeChat) Request(method, urlStr string, data interface{}) ([]byte, error) {
+	return nil, errFakeChat
+}
+
+// emojiTrain is a path drawn with custom emoji, as long as a path gets.
+func emojiTrain(tiles int) string {
+	line := "    Xena >>"
+	for i := 0; i < tiles; i++ {
+		line += fmt.Sprintf(" %d:<:d%d:4567890123%d><:d%d:4567890123%d>", i, i%19, i%19, (i+1)%19, (i+1)%19)
+	}
+
+	return line + " *"
+}
+
+func TestPaginate(t *testing.T) {
+	var lines []string
+	for i := 0; i < 40; i++ {
+		lines = append(lines, emojiTrain(i))
+	}
+	text := strings.Join(lines, "\n")
+
+	pages := paginate(text, maxMessage)
+	if len(pages) < 2 {
+		t.Fatalf("wanted the board spread over pages, got %d", len(pages))
+	}
+
+	for i, page := range pages {
+		if n := utf8.RuneCountInString(page); n > maxMessage {
+			t.Errorf("page %d is %d characters long", i, n)
+		}
+	}
+
+	// Every train is whole on one page, in order.
+	if got := strings.Join(pages, "\n"); got != text {
+		t.Fatalf("trains were split or lost:\n%s", got)
+	}
+}
+
+func TestPaginateLongLine(t *testing.T) {
+	line := emojiTrain(200)
+	pages := paginate("center [6||6]\n"+line, maxMessage)
+
+	for i, page := range pages {
+		if n := utf8.RuneCountInString(page); n > maxMessage {
+			t.Errorf("page %d is %d characters long", i, n)
+		}
+	}
+	if !strings.HasSuffix(pages[len(pages)-1], " *") {
+		t.Fatalf("the end of the train was cut off: %q", pages[len(pages)-1])
+	}
+}
+
+var (
+	wholeEmoji = regexp.MustCompile(`<:d\d+:\d+>`)
+	halfEmoji  = regexp.MustCompile(`<:|\d>`) // What is left of one cut in half.
+)
+
+func TestShortenEmoji(t *testing.T) {
+	line := emojiTrain(30)
+
+	for limit := 20; limit < utf8.RuneCountInString(line); limit++ {
+		got := shorten(line, limit)
+		if n := utf8.RuneCountInString(got); n > limit {
+			t.Fatalf("limit %d: got %d characters", limit, n)
+		}
+
+		if rest := wholeEmoji.ReplaceAllString(got, ""); halfEmoji.MatchString(rest) {
+			t.Fatalf("limit %d: cut an emoji in half: %q", limit, got)
+		}
+	}
+
+	// A line that starts with emoji, and has no spaces to cut at.
+	line = strings.Repeat("<:d12:456789012345>", 10)
+	for limit := 10; limit < utf8.RuneCountInString(line); limit++ {
+		got := shorten(line, limit)
+		if rest := wholeEmoji.ReplaceAllString(got, ""); halfEmoji.MatchString(rest) {
+			t.Fatalf("limit %d: cut an emoji in half: %q", limit, got)
+		}
+	}
+}
+
+func TestHeaded(t *testing.T) {
+	var lines []string
+	for i := 0; i < 60; i++ {
+		lines = append(lines, emojiTrain(i%30))
+	}
+
+	pages := headed(boardHeader, strings.Join(lines, "\n"))
+	for i, page := range pages {
+		if n := utf8.RuneCountInString(page); n > maxMessage {
+			t.Errorf("page %d is %d characters long", i, n)
+		}
+
+		want := fmt.Sprintf("%s (%d/%d)\n", boardHeader, i+1, len(pages))
+		if !strings.HasPrefix(page, want) {
+			t.Errorf("page %d doesn't start with %q", i, want)
+		}
+	}
+}
+
+func TestPostPages(t *testing.T) {
+	chat := newFakeChat()
+	b := &bot{chat: chat}
+
+	check := func(ids, pages []string) {
+		t.Helper()
+
+		if len(chat.messages["c"]) != len(ids) {
+			t.Fatalf("wanted %d messages, the channel has %d", len(ids), len(chat.messages["c"]))
+		}
+		for i, id := range ids {
+			if chat.messages["c"][id] != pages[i] {
+				t.Fatalf("message %s says %q, wanted %q", id, chat.messages["c"][id], pages[i])
+			}
+		}
+	}
+
+	// Growing sends new pages after the old ones.
+	ids, err := b.postPages("c", nil, []string{"a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	check(ids, []string{"a"})
+
+	grown := []string{"a2", "b2", "c2"}
+	ids2, err := b.postPages("c", ids, grown)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids2[0] != ids[0] || chat.edits != 1 {
+		t.Fatalf("the first page should have been edited, got %v", ids2)
+	}
+	check(ids2, grown)
+
+	// Shrinking deletes what is left over.
+	ids3, err := b.postPages("c", ids2, []string{"a3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(ids3, ids2[:1]) || chat.deletes != 2 {
+		t.Fatalf("wanted %v with 2 deleted, got %v with %d", ids2[:1], ids3, chat.deletes)
+	}
+	check(ids3, []string{"a3"})
+}
+
+func TestPostPagesSendFails(t *testing.T) {
+	chat := newFakeChat()
+	b := &bot{chat: chat}
+
+	ids, err := b.postPages("c", nil, []string{"a", "b", "c"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The second message was deleted by hand, and sending its page again
+	// fails, so the third is kept for next time.
+	chat.ChannelMessageDelete("c", ids[1])
+	chat.sendsLeft = 0
+
+	got, err := b.postPages("c", ids, []string{"a2", "b2", "c2"})
+	if err != errFakeChat {
+		t.Fatalf("wanted %v, got %v", errFakeChat, err)
+	}
+	if want := []string{ids[0], ids[2]}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("wanted %v, got %v", want, got)
+	}
+
+	chat.sendsLeft = -1
+	got, err = b.postPages("c", got, []string{"a3", "b3", "c3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got[0] != ids[0] || got[1] != ids[2] || len(chat.messages["c"]) != 3 {
+		t.Fatalf("the messages kept weren't reused: %v", got)
+	}
+}
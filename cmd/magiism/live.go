@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/cetacean/magiism/dominos/game"
+)
+
+// Headers of the messages kept up to date in every game's channel. The board
+// is found again among the channel's pins by its header after a restart.
+const (
+	boardHeader    = "**Mexican Train**"
+	activityHeader = "**Latest moves**"
+)
+
+// activityLines is how much of the log the activity message shows.
+const activityLines = 10
+
+// liveBoard is the pinned board of a game, which takes more than one message
+// on a crowded table, and the message under it showing the latest moves.
+type liveBoard struct {
+	BoardIDs []string
+	Board    string
+
+	LogIDs []string
+	Log    []string
+}
+
+// liveBoard returns the messages of the game in a channel, looking for the
+// board among the pinned messages the first time it is asked about a game
+// that is already going.
+func (b *bot) liveBoard(channelID string) *liveBoard {
+	ch := b.channel(channelID)
+	if ch.board != nil {
+		return ch.board
+	}
+
+	lb := &liveBoard{}
+	ch.board = lb
+
+	pinned, err := b.chat.ChannelMessagesPinned(channelID)
+	if err != nil {
+		log.Printf("can't look for the board in %s: %v", channelID, err)
+		return lb
+	}
+
+	b.mu.Lock()
+	self := b.self
+	b.mu.Unlock()
+
+	for _, m := range pinned {
+		mine := m.Author == nil || self == "" || m.Author.ID == self
+		if mine && strings.HasPrefix(m.Content, boardHeader) {
+			lb.BoardIDs = append(lb.BoardIDs, m.ID)
+		}
+	}
+
+	// Pages were sent in order, and IDs grow over time.
+	sort.Slice(lb.BoardIDs, func(i, j int) bool {
+		a, b := lb.BoardIDs[i], lb.BoardIDs[j]
+		return len(a) < len(b) || (len(a) == len(b) && a < b)
+	})
+
+	return lb
+}
+
+// updateBoard brings the board and activity messages of the game in the
+// call's channel up to date, adding lines to the activity. Once the match is
+// over, the board shows the final standings and is unpinned.
+func (b *bot) updateBoard(c *call, g *game.Game, lines []string) {
+	lb := b.liveBoard(c.ChannelID)
+
+	over := g.Phase == game.MatchOver
+	text := board(g, b.renderer(c.ChannelID, ""))
+	if over {
+		text = standings(g)
+	}
+
+	if text != lb.Board {
+		ids, perr := b.postPages(c.ChannelID, lb.BoardIDs, headed(boardHeader, text))
+		if perr != nil {
+			log.Printf("can't show the board in %s: %v", c.ChannelID, perr)
+		}
+
+		for _, id := range ids {
+			if !contains(lb.BoardIDs, id) {
+				err := b.chat.ChannelMessagePin(c.ChannelID, id)
+				if err != nil {
+					log.Printf("can't pin the board in %s: %v", c.ChannelID, err)
+				}
+			}
+		}
+
+		// Keep what got sent even if some pages didn't, and try the rest
+		// again next time.
+		lb.BoardIDs = ids
+		if perr == nil {
+			lb.Board = text
+		}
+	}
+
+	if len(lines) > 0 {
+		lb.Log = append(lb.Log, lines...)
+		if len(lb.Log) > activityLines {
+			lb.Log = lb.Log[len(lb.Log)-activityLines:]
+		}
+
+		ids, err := b.postPages(c.ChannelID, lb.LogIDs, headed(activityHeader, strings.Join(lb.Log, "\n")))
+		if err != nil {
+			log.Printf("can't show the latest moves in %s: %v", c.ChannelID, err)
+		}
+		lb.LogIDs = ids
+	}
+
+	if over {
+		for _, id := range lb.BoardIDs {
+			err := b.chat.ChannelMessageUnpin(c.ChannelID, id)
+			if err != nil {
+				log.Printf("can't unpin the board in %s: %v", c.ChannelID, err)
+			}
+		}
+
+		b.channel(c.ChannelID).board = nil
+	}
+}
+
+// contains returns true if s is one of ss.
+func contains(ss []string, s string) bool {
+	for _, x := range ss {
+		if x == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+// standings lists the final scores of a match, best first, then whoever
+// forfeited.
+func standings(g *game.Game) string {
+	lines := []string{fmt.Sprintf("The match is over after %d rounds. Final standings:", g.Round)}
+	for i, id := range ranked(g) {
+		lines = append(lines, fmt.Sprintf("%d. %s with %d", i+1, name(id), g.Scores[id]))
+	}
+	if gone := forfeited(g); len(gone) > 0 {
+		lines = append(lines, "Forfeited: "+strings.Join(gone, ", "))
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cetacean/magiism/dominos/ai"
+)
+
+// boards returns the IDs of the messages in a channel showing a board.
+func boards(chat *fakeChat, channelID string) []string {
+	chat.mu.Lock()
+	defer chat.mu.Unlock()
+
+	var ids []string
+	for id, m := range chat.messages[channelID] {
+		if strings.HasPrefix(m, boardHeader) && chat.authors[id] == "" {
+			ids = append(ids, id)
+		}
+	}
+
+	return ids
+}
+
+func TestBoardPinned(t *testing.T) {
+	b, chat := testBot(t)
+	seatBoard(t, b, "c", duel, 1)
+
+	run(t, b, chat, []step{
+		{ChannelID: "c", AuthorID: "alice", Content: "!mt play 6|1 0", Want: "It's <@bob>'s turn."},
+	})
+
+	ids := boards(chat, "c")
+	if len(ids) != 1 || !reflect.DeepEqual(chat.pinned("c"), ids) {
+		t.Fatalf("wanted the board pinned, the boards are %v and the pins %v", ids, chat.pinned("c"))
+	}
+	if moves := find(chat, "c", activityHeader); !strings.Contains(moves, "<@alice> played [6|1]") {
+		t.Fatalf("the latest moves are missing alice's:\n%s", moves)
+	}
+
+	// Someone else pinned something that looks like a board.
+	fake, _ := chat.ChannelMessageSend("c", boardHeader+" by hand")
+	chat.authors[fake.ID] = "someone"
+	chat.ChannelMessagePin("c", fake.ID)
+
+	// After a restart the board is found among the pins and kept up to
+	// date, rather than another one being posted.
+	b = newBot(chat, b.store, ai.NewRegistry(), "!mt")
+	run(t, b, chat, []step{
+		{ChannelID: "c", AuthorID: "bob", Content: "!mt play 6|5 1", Want: "It's <@alice>'s turn."},
+	})
+
+	if got := boards(chat, "c"); !reflect.DeepEqual(got, ids) {
+		t.Fatalf("wanted the board %v kept, got %v", ids, got)
+	}
+	if board := chat.inOrder("c")[0]; !strings.Contains(board, "[6|5]") {
+		t.Fatalf("the board wasn't brought up to date:\n%s", board)
+	}
+	if got := chat.messages["c"][fake.ID]; got != boardHeader+" by hand" {
+		t.Fatalf("someone else's message was edited to %q", got)
+	}
+}
+
+func TestBoardMatchOver(t *testing.T) {
+	b, chat := testBot(t)
+	seatBoard(t, b, "c", lastMove, 7)
+
+	run(t, b, chat, []step{
+		{ChannelID: "c", AuthorID: "alice", Content: "!mt play 1|2 0", Want: "The match is over! <@alice> wins."},
+	})
+
+	board := find(chat, "c", boardHeader)
+	if !strings.Contains(board, "Final standings:\n1. <@alice> with 0\n2. <@bob> with 7") {
+		t.Fatalf("the board should show the standings:\n%s", board)
+	}
+	if pins := chat.pinned("c"); len(pins) != 0 {
+		t.Fatalf("the board of a finished match is still pinned: %v", pins)
+	}
+	if b.channel("c").board != nil {
+		t.Fatal("the board of a finished match was kept")
+	}
+}
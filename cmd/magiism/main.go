@@ -2,42 +2,67 @@ package main
 
 import (
 	"flag"
-	"fmt"
 	"log"
 	"runtime"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
+	"github.com/cetacean/magiism/dominos/ai"
+	"github.com/cetacean/magiism/dominos/game"
 	"github.com/facebookgo/flagenv"
 )
 
 var (
 	username = flag.String("username", "", "discord username")
 	password = flag.String("password", "", "discord password")
+
+	prefix     = flag.String("prefix", "!mt", "what commands start with, besides mentioning the bot")
+	games      = flag.String("games", "games", "directory to keep games in")
+	remoteBots = flag.String("remote-bots", "remote-bots.json", "file listing the remote bots set up by each guild")
+	styles     = flag.String("styles", "styles.json", "file keeping the style every player sees tiles in")
+	tick       = flag.Duration("tick", 5*time.Second, "how often to check the clocks of timed games")
 )
 
 func main() {
 	flag.Parse()
 	flagenv.Parse()
 
+	registry, err := ai.LoadRegistry(*remoteBots)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	store, err := game.NewFileStore(*games)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	chosen, err := loadStyles(*styles)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	d, err := discordgo.New(*username, *password)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	d.AddHandler(messageCreate)
+	b := newBot(d, store, registry, *prefix)
+	b.styles, b.stylesFile = chosen, *styles
+	d.AddHandler(b.messageCreate)
+	d.AddHandler(b.guildCreate)
+	d.AddHandler(b.guildEmojisUpdate)
 
 	err = d.Open()
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	runtime.Goexit()
-}
+	go func() {
+		for range time.Tick(*tick) {
+			b.tick()
+		}
+	}()
 
-// This function will be called (due to AddHandler above) every time a new
-// message is created on any channel that the autenticated bot has access to.
-func messageCreate(s *discordgo.Session, m *discordgo.MessageCreate) {
-	// Print message to stdout.
-	fmt.Printf("%20s %20s %20s > %s\n", m.ChannelID, time.Now().Format(time.Stamp), m.Author.Username, m.Content)
+	runtime.Goexit()
 }
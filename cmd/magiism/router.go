@@ -0,0 +1,529 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/cetacean/magiism/dominos"
+	"github.com/cetacean/magiism/dominos/ai"
+	"github.com/cetacean/magiism/dominos/game"
+	"github.com/cetacean/magiism/dominos/lobby"
+)
+
+// chat is the part of a Discord session the bot talks through.
+type chat interface {
+	ChannelMessageSend(channelID, content string) (*discordgo.Message, error)
+	ChannelMessageEdit(channelID, messageID, content string) (*discordgo.Message, error)
+	ChannelMessageDelete(channelID, messageID string) error
+	ChannelFileSend(channelID, name string, r io.Reader) (*discordgo.Message, error)
+	ChannelMessagePin(channelID, messageID string) error
+	ChannelMessageUnpin(channelID, messageID string) error
+	ChannelMessagesPinned(channelID string) ([]*discordgo.Message, error)
+	UserChannelCreate(recipientID string) (*discordgo.Channel, error)
+	Channel(channelID string) (*discordgo.Channel, error)
+	Guild(guildID string) (*discordgo.Guild, error)
+	UserChannelPermissions(userID, channelID string) (int, error)
+	Request(method, urlStr string, data interface{}) ([]byte, error)
+}
+
+// bot plays Mexican Train in Discord channels, one game per channel. Games
+// are kept in the store under the ID of their channel, lobbies only live in
+// memory until their game starts. Every player's hand is kept in a message in
+// their DMs, where they can also send commands for their game.
+type bot struct {
+	chat     chat
+	store    *game.FileStore
+	registry *ai.Registry
+	prefix   string
+
+	// mu guards what follows. It is only held while looking at them, never
+	// while waiting on Discord or a bot, so that a slow game doesn't hold up
+	// the others.
+	mu       sync.Mutex
+	self     string                    // The bot's own user ID, once known.
+	channels map[string]*channel       // By channel ID.
+	guilds   map[string]string         // Guild ID of every channel seen.
+	dms      map[string]string         // DM channel of every player.
+	playing  map[string]string         // Channel of every player's last game.
+	emoji    map[string]dominos.Emojis // Pip emoji, by guild ID.
+
+	styles     map[string]string // Style every player chose to see tiles in.
+	stylesFile string            // Where styles are kept, if anywhere.
+}
+
+// channel is what the bot keeps about the lobby or game in one channel.
+type channel struct {
+	// mu is held for as long as a command in the channel is handled, bots'
+	// moves and messages included, so that only one thing at a time touches
+	// the game and the fields below.
+	mu      sync.Mutex
+	lobby   *lobby.Lobby
+	board   *liveBoard
+	hands   map[string]*handMsg // By player ID.
+	blocked map[string]bool     // Players who were told their DMs are closed.
+
+	ticking bool // A tick is waiting for the channel, guarded by bot.mu.
+}
+
+func newBot(c chat, store *game.FileStore, registry *ai.Registry, prefix string) *bot {
+	return &bot{
+		chat:     c,
+		store:    store,
+		registry: registry,
+		prefix:   prefix,
+		channels: map[string]*channel{},
+		guilds:   map[string]string{},
+		dms:      map[string]string{},
+		playing:  map[string]string{},
+		emoji:    map[string]dominos.Emojis{},
+		styles:   map[string]string{},
+	}
+}
+
+// call is a single command being handled, and what to say back. Commands
+// sent in a DM are handled as if they were sent in the channel of the
+// author's game.
+type call struct {
+	ChannelID string
+	AuthorID  string
+	Args      []string
+	Private   bool // Sent in a DM.
+
+	out   []string
+	told  []note
+	files []file
+}
+
+// note is something to tell a single player.
+type note struct {
+	PlayerID string
+	Text     string
+}
+
+// file is an attachment to the reply.
+type file struct {
+	PlayerID string // Who to send it to, or everyone if empty.
+	Name     string
+	Data     []byte
+	Alt      string // Said instead if the file can't be sent.
+}
+
+// say adds a line to the reply.
+func (c *call) say(format string, args ...interface{}) {
+	c.out = append(c.out, fmt.Sprintf(format, args...))
+}
+
+// tell adds a line to the reply meant for a single player.
+func (c *call) tell(id, format string, args ...interface{}) {
+	c.told = append(c.told, note{PlayerID: id, Text: fmt.Sprintf(format, args...)})
+}
+
+// reply answers the author where they sent the command, rather than telling
+// the whole channel.
+func (c *call) reply(format string, args ...interface{}) {
+	if c.Private {
+		c.tell(c.AuthorID, format, args...)
+		return
+	}
+
+	c.say(format, args...)
+}
+
+// attach adds a file to the reply, sent to the author if they sent the
+// command in a DM.
+func (c *call) attach(name string, data []byte, alt string) {
+	f := file{Name: name, Data: data, Alt: alt}
+	if c.Private {
+		f.PlayerID = c.AuthorID
+	}
+
+	c.files = append(c.files, f)
+}
+
+// command is something players can ask the bot to do.
+type command struct {
+	Name string
+	Args string // How the arguments look, for help.
+	Help string
+	Run  func(b *bot, c *call) error
+}
+
+// commands lists every command, in the order help shows them. It is filled
+// in by init since help needs it.
+var commands []command
+
+func init() {
+	commands = []command{
+		{Name: "new", Args: "[rules]", Help: "open a lobby in this channel", Run: (*bot).newLobby},
+		{Name: "join", Help: "join the lobby", Run: (*bot).join},
+		{Name: "leave", Help: "leave the lobby", Run: (*bot).leave},
+		{Name: "addbot", Args: "<kind>", Help: "seat a bot in the lobby", Run: (*bot).addBot},
+		{Name: "start", Help: "deal the game, if you are the host", Run: (*bot).start},
+		{Name: "play", Args: "<tile> <path>", Help: "play a tile from your hand, such as 3 or 6|4, on a path, such as 0 or m", Run: (*bot).play},
+		{Name: "draw", Help: "draw a tile from the boneyard", Run: (*bot).draw},
+		{Name: "knock", Help: "knock when you have one tile left", Run: (*bot).knock},
+		{Name: "end", Help: "end your turn", Run: (*bot).endTurn},
+		{Name: "hint", Help: "ask what to play", Run: (*bot).hint},
+		{Name: "board", Args: "[text]", Help: "show a picture of the board, or the board as text", Run: (*bot).board},
+		{Name: "hand", Help: "have your hand sent to you", Run: (*bot).hand},
+		{Name: "forfeit", Args: "[bot|keep]", Help: "leave the game, handing your seat to a bot or keeping it open", Run: (*bot).forfeit},
+		{Name: "take", Args: "<player>", Help: "take over a seat that was handed to a bot or kept open", Run: (*bot).take},
+		{Name: "style", Args: "[name]", Help: "pick how you see tiles", Run: (*bot).style},
+		{Name: "emojis", Help: "add the pip emoji to this server", Run: (*bot).emojis},
+		{Name: "help", Help: "show this", Run: (*bot).help},
+	}
+}
+
+// parse returns the words of a message meant for the bot, which starts with
+// the prefix or a mention of the bot as a word of its own. Everything in a DM
+// is meant for the bot, so there they are optional.
+func (b *bot) parse(content, self string, private bool) ([]string, bool) {
+	content = strings.TrimSpace(content)
+
+	var starts []string
+	if b.prefix != "" {
+		starts = append(starts, b.prefix)
+	}
+	if self != "" {
+		starts = append(starts, "<@"+self+">", "<@!"+self+">")
+	}
+
+	rest, ok := content, private
+	for _, start := range starts {
+		if r, found := cutWord(content, start); found {
+			rest, ok = r, true
+			break
+		}
+	}
+	if !ok {
+		return nil, false
+	}
+
+	args := strings.Fields(rest)
+	if len(args) == 0 {
+		args = []string{"help"}
+	}
+	args[0] = strings.ToLower(args[0])
+
+	return args, true
+}
+
+// cutWord returns what comes after word at the start of s, if s starts with
+// it and it isn't just the start of a longer word.
+func cutWord(s, word string) (string, bool) {
+	if !strings.HasPrefix(s, word) {
+		return "", false
+	}
+
+	rest := s[len(word):]
+	if rest != "" && !unicode.IsSpace([]rune(rest)[0]) {
+		return "", false
+	}
+
+	return rest, true
+}
+
+// messageCreate is called by discordgo for every message the bot can see.
+func (b *bot) messageCreate(s *discordgo.Session, m *discordgo.MessageCreate) {
+	if m.Author == nil || m.Author.Bot {
+		return
+	}
+
+	var self string
+	if s.State != nil && s.State.User != nil {
+		self = s.State.User.ID
+	}
+
+	b.message(m.ChannelID, m.Author.ID, m.Content, self)
+}
+
+// message handles a message if it is meant for the bot.
+func (b *bot) message(channelID, authorID, content, self string) {
+	if self != "" {
+		b.mu.Lock()
+		b.self = self
+		b.mu.Unlock()
+	}
+
+	// Only DMs are outside of guilds.
+	guild, err := b.guild(channelID)
+	if err != nil {
+		log.Printf("can't look up %s: %v", channelID, err)
+		return
+	}
+	private := guild == ""
+
+	args, ok := b.parse(content, self, private)
+	if !ok {
+		return
+	}
+
+	c := &call{
+		ChannelID: channelID,
+		AuthorID:  authorID,
+		Args:      args,
+		Private:   private,
+	}
+
+	if private {
+		b.mu.Lock()
+		b.dms[authorID] = channelID
+		b.mu.Unlock()
+
+		c.ChannelID = b.gameOf(authorID)
+	}
+
+	ch := b.channel(c.ChannelID)
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+
+	b.handle(c)
+}
+
+// channel returns what the bot keeps about a channel.
+func (b *bot) channel(id string) *channel {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ch, ok := b.channels[id]
+	if !ok {
+		ch = &channel{hands: map[string]*handMsg{}, blocked: map[string]bool{}}
+		b.channels[id] = ch
+	}
+
+	return ch
+}
+
+// setPlaying remembers the channel of a player's last game.
+func (b *bot) setPlaying(id, channelID string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.playing[id] = channelID
+}
+
+// gameOf returns the channel of the game a player is in. If they are in
+// more than one, the one they last got a hand for wins.
+func (b *bot) gameOf(id string) string {
+	b.mu.Lock()
+	ch, ok := b.playing[id]
+	b.mu.Unlock()
+	if ok {
+		return ch
+	}
+
+	// After a restart, go looking.
+	ids, err := b.store.Games()
+	if err != nil {
+		log.Printf("can't list games: %v", err)
+		return ""
+	}
+
+	for _, ch := range ids {
+		g, err := b.store.GetGame(ch)
+		if err != nil {
+			continue
+		}
+
+		if _, ok := g.GetPlayerByID(id); ok {
+			b.setPlaying(id, ch)
+			return ch
+		}
+	}
+
+	return ""
+}
+
+// handle runs a command and sends the reply. The caller has to hold the lock
+// of the call's channel.
+func (b *bot) handle(c *call) {
+	var cmd *command
+	for i := range commands {
+		if commands[i].Name == c.Args[0] {
+			cmd = &commands[i]
+		}
+	}
+
+	switch {
+	case cmd == nil:
+		c.reply("I don't know how to %s, try `%s help`", c.Args[0], b.prefix)
+	case c.Private && c.ChannelID == "" && cmd.Name != "help":
+		c.reply("You are not playing in any game, join one in a channel first")
+	default:
+		if err := cmd.Run(b, c); err != nil {
+			c.reply("%s", friendly(err))
+		}
+	}
+
+	b.send(c)
+}
+
+// send posts the reply to a call, if there is one, and tells every player
+// what is meant for them alone.
+func (b *bot) send(c *call) {
+	if len(c.out) > 0 && c.ChannelID != "" {
+		for _, page := range paginate(strings.Join(c.out, "\n"), maxMessage) {
+			_, err := b.chat.ChannelMessageSend(c.ChannelID, page)
+			if err != nil {
+				log.Printf("can't send to %s: %v", c.ChannelID, err)
+			}
+		}
+	}
+
+	for _, f := range c.files {
+		ch := c.ChannelID
+		if f.PlayerID != "" {
+			dm, err := b.dm(f.PlayerID)
+			if err != nil {
+				c.told = append(c.told, note{PlayerID: f.PlayerID, Text: f.Alt})
+				continue
+			}
+			ch = dm
+		}
+
+		_, err := b.chat.ChannelFileSend(ch, f.Name, bytes.NewReader(f.Data))
+		if err == nil {
+			continue
+		}
+		log.Printf("can't send %s to %s: %v", f.Name, ch, err)
+
+		for _, page := range paginate(f.Alt, maxMessage) {
+			_, err := b.chat.ChannelMessageSend(ch, page)
+			if err != nil {
+				log.Printf("can't send to %s: %v", ch, err)
+			}
+		}
+	}
+
+	var order []string
+	told := map[string][]string{}
+	for _, n := range c.told {
+		if _, ok := told[n.PlayerID]; !ok {
+			order = append(order, n.PlayerID)
+		}
+		told[n.PlayerID] = append(told[n.PlayerID], n.Text)
+	}
+
+	for _, id := range order {
+		text := strings.Join(told[id], "\n")
+		if err := b.whisper(id, text); err == nil || c.ChannelID == "" {
+			continue
+		}
+
+		// Their DMs are closed, so the channel will have to do.
+		for _, page := range paginate(name(id)+" "+text, maxMessage) {
+			_, err := b.chat.ChannelMessageSend(c.ChannelID, page)
+			if err != nil {
+				log.Printf("can't send to %s: %v", c.ChannelID, err)
+			}
+		}
+	}
+}
+
+// whisper sends a message to a player's DMs, in as many pages as it takes.
+func (b *bot) whisper(id, text string) error {
+	ch, err := b.dm(id)
+	if err != nil {
+		return err
+	}
+
+	for _, page := range paginate(text, maxMessage) {
+		if _, err := b.chat.ChannelMessageSend(ch, page); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// dm returns the ID of a player's DM channel.
+func (b *bot) dm(id string) (string, error) {
+	b.mu.Lock()
+	ch, ok := b.dms[id]
+	b.mu.Unlock()
+	if ok {
+		return ch, nil
+	}
+
+	dm, err := b.chat.UserChannelCreate(id)
+	if err != nil {
+		return "", err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.dms[id] = dm.ID
+	b.guilds[dm.ID] = ""
+	return dm.ID, nil
+}
+
+// guild returns the ID of the guild a channel is in.
+func (b *bot) guild(channelID string) (string, error) {
+	b.mu.Lock()
+	id, ok := b.guilds[channelID]
+	b.mu.Unlock()
+	if ok {
+		return id, nil
+	}
+
+	ch, err := b.chat.Channel(channelID)
+	if err != nil {
+		return "", err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.guilds[channelID] = ch.GuildID
+	return ch.GuildID, nil
+}
+
+// friendly turns an error into something to say, without the name of the
+// package it came from.
+func friendly(err error) string {
+	msg := err.Error()
+	if i := strings.Index(msg, ": "); i > 0 && !strings.Contains(msg[:i], " ") {
+		msg = msg[i+2:]
+	}
+
+	return strings.ToUpper(msg[:1]) + msg[1:]
+}
+
+// botPrefix starts the player IDs of bots seated from a lobby, followed by
+// the kind of bot.
+const botPrefix = "bot:"
+
+// name returns how to refer to a player in a message.
+func name(id string) string {
+	if strings.HasPrefix(id, botPrefix) {
+		return strings.TrimPrefix(id, botPrefix) + " (bot)"
+	}
+
+	return "<@" + id + ">"
+}
+
+// names replaces the IDs of the game's players in text with their names.
+func names(g *game.Game, text string) string {
+	var ids []string
+	for _, p := range g.Players {
+		ids = append(ids, p.ID)
+	}
+
+	// Longer IDs first, so that a bot's ID never eats into another's.
+	sort.Slice(ids, func(i, j int) bool { return len(ids[i]) > len(ids[j]) })
+
+	var pairs []string
+	for _, id := range ids {
+		pairs = append(pairs, id, name(id))
+	}
+
+	return strings.NewReplacer(pairs...).Replace(text)
+}
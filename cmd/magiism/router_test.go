@@ -0,0 +1,269 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cetacean/magiism/dominos"
+	"github.com/cetacean/magiism/dominos/ai"
+	"github.com/cetacean/magiism/dominos/game"
+	"github.com/cetacean/magiism/dominos/lobby"
+)
+
+func TestParse(t *testing.T) {
+	b := &bot{prefix: "!mt"}
+
+	tests := []struct {
+		content string
+		private bool
+		want    []string // Nil if the message isn't meant for the bot.
+	}{
+		{content: "!mt new", want: []string{"new"}},
+		{content: "  !mt  play 3  m ", want: []string{"play", "3", "m"}},
+		{content: "!mt", want: []string{"help"}},
+		{content: "!mt\tJOIN", want: []string{"join"}},
+		{content: "!mtfoo"},
+		{content: "!mtnew game"},
+		{content: "hello !mt new"},
+		{content: "<@me> join", want: []string{"join"}},
+		{content: "<@!me> Start", want: []string{"start"}},
+		{content: "<@me>join"},
+		{content: "<@someone> join"},
+		{content: "draw"},
+		{content: "draw", private: true, want: []string{"draw"}},
+		{content: "!mt draw", private: true, want: []string{"draw"}},
+		{content: "<@me> draw", private: true, want: []string{"draw"}},
+		{content: "", private: true, want: []string{"help"}},
+	}
+
+	for _, tt := range tests {
+		got, ok := b.parse(tt.content, "me", tt.private)
+		if ok != (tt.want != nil) || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q (private %v): wanted %q, got %q (%v)", tt.content, tt.private, tt.want, got, ok)
+		}
+	}
+}
+
+// testBot returns a bot with nothing going on, talking through a fake chat.
+func testBot(t *testing.T) (*bot, *fakeChat) {
+	store, err := game.NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chat := newFakeChat()
+	return newBot(chat, store, ai.NewRegistry(), "!mt"), chat
+}
+
+// seatBoard has a game from a board fixture going on in a channel.
+func seatBoard(t *testing.T, b *bot, channelID, board string, round int) {
+	dg, err := dominos.ParseBoard(board)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := &game.Game{
+		Game:   dg,
+		Phase:  game.Playing,
+		Round:  round,
+		Rounds: 7,
+		Scores: map[string]int{},
+		Rules:  game.Standard,
+	}
+	if err := b.store.PutGame(channelID, g); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// last returns the last thing said in a channel.
+func last(chat *fakeChat, channelID string) string {
+	said := chat.inOrder(channelID)
+	if len(said) == 0 {
+		return ""
+	}
+
+	return said[len(said)-1]
+}
+
+// find returns the message of a channel that starts with header.
+func find(chat *fakeChat, channelID, header string) string {
+	for _, m := range chat.inOrder(channelID) {
+		if strings.HasPrefix(m, header) {
+			return m
+		}
+	}
+
+	return ""
+}
+
+// step is a message sent to the bot, and what it should have said last in
+// a channel afterwards.
+type step struct {
+	ChannelID string
+	AuthorID  string
+	Content   string
+	Where     string // Channel of the reply, the one sent to if empty.
+	Want      string // Part of the reply.
+}
+
+func run(t *testing.T, b *bot, chat *fakeChat, steps []step) {
+	t.Helper()
+
+	for i, s := range steps {
+		b.message(s.ChannelID, s.AuthorID, s.Content, "me")
+
+		where := s.Where
+		if where == "" {
+			where = s.ChannelID
+		}
+		if got := last(chat, where); !strings.Contains(got, s.Want) {
+			t.Fatalf("step %d, %s says %q: wanted %q in %s, got %q", i, s.AuthorID, s.Content, s.Want, where, got)
+		}
+	}
+}
+
+func TestLobbyToGame(t *testing.T) {
+	b, chat := testBot(t)
+
+	run(t, b, chat, []step{
+		{ChannelID: "c", AuthorID: "alice", Content: "!mt join", Want: friendly(errNoLobby)},
+		{ChannelID: "c", AuthorID: "alice", Content: "!mt new nonsense", Want: friendly(errUnknownRule)},
+		{ChannelID: "c", AuthorID: "alice", Content: "!mt new", Want: "<@alice> opened a lobby"},
+		{ChannelID: "c", AuthorID: "bob", Content: "!mt new", Want: friendly(errLobbyOpen)},
+		{ChannelID: "c", AuthorID: "bob", Content: "!mt join", Want: "<@bob> joined, that makes 2 players"},
+		{ChannelID: "c", AuthorID: "bob", Content: "!mt join", Want: friendly(lobby.ErrAlreadyJoined)},
+		{ChannelID: "c", AuthorID: "bob", Content: "!mt start", Want: friendly(lobby.ErrNotHost)},
+		{ChannelID: "c", AuthorID: "alice", Content: "!mt dance", Want: "I don't know how to dance, try `!mt help`"},
+		{ChannelID: "c", AuthorID: "alice", Content: "!mt start", Want: "'s turn."},
+		{ChannelID: "c", AuthorID: "bob", Content: "!mt new", Want: friendly(errGameRunning)},
+	})
+
+	g, err := b.store.GetGame("c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(g.Players) != 2 || g.Phase != game.BigTurn {
+		t.Fatalf("wanted 2 players in the big turn, got %d in %s", len(g.Players), g.Phase)
+	}
+
+	for _, id := range []string{"alice", "bob"} {
+		if len(chat.inOrder("dm-"+id)) == 0 {
+			t.Errorf("%s wasn't sent their hand", id)
+		}
+	}
+
+	// Commands sent in a DM are played in the channel of the game, and
+	// answered in the DM.
+	waiting := g.Players[(g.ActivePlayer+1)%2].ID
+	run(t, b, chat, []step{
+		{ChannelID: "dm-" + waiting, AuthorID: waiting, Content: "draw", Want: friendly(game.ErrNotYourTurn)},
+		{ChannelID: "dm-carol", AuthorID: "carol", Content: "draw", Want: "You are not playing in any game"},
+	})
+}
+
+// duel is a game between alice and bob, where alice is about to win.
+const duel = `
+center [6||6]
+turn alice
+alice >>
+  bob >>
+    M >>
+hand alice: [6|1] [1|2] [2|3]
+hand bob: [6|5] [3|4] [0|0]
+pool: [2|2] [4|4] [0|1]
+`
+
+// lastMove is the last round of a match, which alice wins with her next
+// move.
+const lastMove = `
+center [6||6]
+turn alice
+alice >> [6|1]
+  bob >> [6|5]
+    M >>
+hand alice: [1|2]
+hand bob: [3|4] [0|0]
+pool: [2|2]
+`
+
+func TestPlayToTheEnd(t *testing.T) {
+	b, chat := testBot(t)
+	seatBoard(t, b, "c", duel, 7)
+
+	run(t, b, chat, []step{
+		{ChannelID: "c", AuthorID: "bob", Content: "!mt draw", Want: friendly(game.ErrNotYourTurn)},
+		{ChannelID: "c", AuthorID: "carol", Content: "!mt draw", Want: friendly(errNotPlaying)},
+		{ChannelID: "c", AuthorID: "alice", Content: "!mt play 5|5 0", Want: friendly(errNoSuchTile)},
+		{ChannelID: "c", AuthorID: "alice", Content: "!mt play 6|1 9", Want: friendly(errNoSuchPath)},
+		{ChannelID: "c", AuthorID: "alice", Content: "!mt play 6|1", Want: friendly(errUsage)},
+		{ChannelID: "c", AuthorID: "alice", Content: "!mt play 6|1 0", Want: "It's <@bob>'s turn."},
+
+		// Bob can't end his turn while he can play.
+		{ChannelID: "c", AuthorID: "bob", Content: "!mt end", Where: "dm-bob", Want: "you can place tile"},
+		{ChannelID: "c", AuthorID: "bob", Content: "!mt play 6|5 1", Want: "It's <@alice>'s turn."},
+
+		{ChannelID: "c", AuthorID: "alice", Content: "!mt play 1|2 0", Want: "It's <@bob>'s turn."},
+	})
+
+	// Alice knocks out of turn, so she isn't made to draw for not knocking.
+	b.message("c", "alice", "!mt knock", "me")
+	if moves := find(chat, "c", activityHeader); !strings.Contains(moves, "<@alice> knocked") {
+		t.Fatalf("the knock wasn't shown with the latest moves:\n%s", moves)
+	}
+
+	run(t, b, chat, []step{
+		{ChannelID: "c", AuthorID: "bob", Content: "!mt end", Where: "dm-bob", Want: game.MustTryDrawingMsg},
+		{ChannelID: "c", AuthorID: "bob", Content: "!mt draw"},
+		{ChannelID: "c", AuthorID: "bob", Content: "!mt end", Want: "It's <@alice>'s turn."},
+	})
+
+	g, err := b.store.GetGame("c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if alice, _ := g.GetPlayerByID("alice"); len(alice.Hand) != 1 || !alice.Knocked {
+		t.Fatalf("alice should have knocked with one tile left, has %v", alice.Hand)
+	}
+	if bob, _ := g.GetPlayerByID("bob"); len(bob.Hand) != 3 || !bob.Path.Train {
+		t.Fatalf("bob should have drawn and put his train up, has %v", bob.Hand)
+	}
+
+	run(t, b, chat, []step{
+		{ChannelID: "c", AuthorID: "alice", Content: "!mt play 2|3 0", Want: "The match is over! <@alice> wins."},
+	})
+
+	if _, err := b.store.GetGame("c"); err != game.ErrGameNotFound {
+		t.Fatalf("the game should be gone once the match is over, got %v", err)
+	}
+}
+
+func TestForfeit(t *testing.T) {
+	b, chat := testBot(t)
+	seatBoard(t, b, "c", duel, 1)
+
+	run(t, b, chat, []step{
+		{ChannelID: "c", AuthorID: "alice", Content: "!mt forfeit nonsense", Want: friendly(errUsage)},
+		{ChannelID: "c", AuthorID: "alice", Content: "!mt play 6|1 0", Want: "It's <@bob>'s turn."},
+
+		// A bot takes bob's turn for him, and it's alice's again.
+		{ChannelID: "c", AuthorID: "bob", Content: "!mt forfeit bot", Want: "It's <@alice>'s turn."},
+	})
+
+	g, err := b.store.GetGame("c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.SeatOf("bob") != game.Bot {
+		t.Fatalf("bob's seat should be played by a bot, got %v", g.SeatOf("bob"))
+	}
+	if bob, _ := g.GetPlayerByID("bob"); len(bob.Path.Elements) == 0 {
+		t.Fatal("the bot didn't play for bob")
+	}
+
+	// With nobody left to wait for, the bot plays out the match.
+	run(t, b, chat, []step{
+		{ChannelID: "c", AuthorID: "alice", Content: "!mt forfeit keep", Want: "The match is over!"},
+		{ChannelID: "c", AuthorID: "alice", Content: "!mt draw", Want: friendly(errNoGame)},
+	})
+}
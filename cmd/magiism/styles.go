@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/cetacean/magiism/dominos"
+)
+
+// emojiStyle is the style that draws tiles with the pip emoji of the server
+// a game is in. The other styles are dominos.Renderers.
+const emojiStyle = "emoji"
+
+// styleNames are the ways players can choose to see tiles in. ANSI colors only
+// show in code blocks on Discord, so that one is left out.
+var styleNames = []string{emojiStyle, "text", "unicode"}
+
+var errUnknownStyle = errors.New("there is no style by that name")
+
+// loadStyles reads the style every player chose from a file. A file that
+// doesn't exist yet holds no choices.
+func loadStyles(fname string) (map[string]string, error) {
+	chosen := map[string]string{}
+
+	data, err := ioutil.ReadFile(fname)
+	if os.IsNotExist(err) {
+		return chosen, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(data, &chosen)
+	if err != nil {
+		return nil, err
+	}
+	if chosen == nil {
+		chosen = map[string]string{}
+	}
+
+	return chosen, nil
+}
+
+// saveStyles writes the style every player chose to the bot's file, if it
+// has one.
+func (b *bot) saveStyles() error {
+	if b.stylesFile == "" {
+		return nil
+	}
+
+	b.mu.Lock()
+	data, err := json.MarshalIndent(b.styles, "", "  ")
+	b.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(b.stylesFile, data, 0644)
+}
+
+// renderer returns how to draw tiles of the game in a channel for a player,
+// or for everyone if playerID is empty. Players who haven't chosen, and
+// everyone, get the server's emoji if it has any and text otherwise.
+func (b *bot) renderer(channelID, playerID string) dominos.Renderer {
+	switch style := b.styleOf(playerID); style {
+	case "":
+		if e := b.emojisOf(channelID); len(e) > 0 {
+			return e
+		}
+		return dominos.Text{}
+
+	case emojiStyle:
+		return b.emojisOf(channelID)
+
+	default:
+		if r, ok := dominos.Renderers[style]; ok {
+			return r
+		}
+		return dominos.Text{}
+	}
+}
+
+// styleOf returns the style a player chose, or "" if they haven't.
+func (b *bot) styleOf(playerID string) string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.styles[playerID]
+}
+
+func (b *bot) style(c *call) error {
+	if len(c.Args) == 1 {
+		current := b.styleOf(c.AuthorID)
+		if current == "" {
+			current = "the server's"
+		}
+
+		c.reply("You see tiles in %s style. Pick one of %s with `%s style <name>`",
+			current, strings.Join(styleNames, ", "), b.prefix)
+		return nil
+	}
+
+	style := strings.ToLower(c.Args[1])
+	if !contains(styleNames, style) {
+		return errUnknownStyle
+	}
+
+	b.mu.Lock()
+	b.styles[c.AuthorID] = style
+	chID, playing := b.playing[c.AuthorID]
+	b.mu.Unlock()
+
+	if err := b.saveStyles(); err != nil {
+		return err
+	}
+
+	c.reply("You now see tiles in %s style", style)
+
+	// Redraw their hand, if they are playing. The game may be in another
+	// channel, whose lock this call doesn't hold, or in this one, whose lock
+	// it does, so it is redrawn once whoever holds that lock is done.
+	if playing {
+		go func() {
+			ch := b.channel(chID)
+			ch.mu.Lock()
+			defer ch.mu.Unlock()
+
+			if g, err := b.game(chID); err == nil {
+				redraw := &call{ChannelID: chID}
+				b.updateHands(redraw, g)
+				b.send(redraw)
+			}
+		}()
+	}
+
+	return nil
+}
@@ -0,0 +1,53 @@
+// Command pips draws the face of every number of pips that can come up in a
+// game, as emoji for Discord and as full size artwork.
+//
+// Faces are written to the -out directory named after their emoji, as
+// dominos.EmojiName names them, with the full size faces under full/ in it.
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/cetacean/magiism/dominos"
+	"github.com/cetacean/magiism/dominos/picture"
+)
+
+var (
+	out  = flag.String("out", "emojis", "directory to write the faces to")
+	max  = flag.Int("max", picture.MaxPips, "highest number of pips to draw")
+	size = flag.Int("size", picture.EmojiSize, "size of the emoji, in pixels")
+	full = flag.Int("full", picture.FullSize, "size of the full size faces, in pixels, or 0 to skip them")
+)
+
+func main() {
+	flag.Parse()
+
+	dirs := map[string]int{*out: *size}
+	if *full > 0 {
+		dirs[filepath.Join(*out, "full")] = *full
+	}
+
+	for dir, size := range dirs {
+		err := os.MkdirAll(dir, 0755)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		for n := 0; n <= *max; n++ {
+			data, err := picture.FacePNG(n, size)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			fname := filepath.Join(dir, dominos.EmojiName(n)+".png")
+			err = ioutil.WriteFile(fname, data, 0644)
+			if err != nil {
+				log.Fatal(err)
+			}
+		}
+	}
+}
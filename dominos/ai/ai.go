@@ -0,0 +1,234 @@
+// Package ai provides computer players for games of Mexican Train.
+package ai
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cetacean/magiism/dominos/game"
+)
+
+// Package errors
+var (
+	ErrNoMoves    = errors.New("ai: there is nothing to do")
+	ErrUnknownBot = errors.New("ai: unknown kind of bot")
+	ErrStuck      = errors.New("ai: bots keep making moves the game won't take")
+)
+
+// Player picks moves for a seat. It is given the seat's view of the game,
+// which always has at least one legal move in it, and must return one of
+// those moves.
+type Player interface {
+	Move(ctx context.Context, v *game.View) (game.Event, error)
+}
+
+// ExecPrefix starts the names of bots that are external engines, followed by
+// the command that runs the engine, as in "exec:python3 bot.py".
+const ExecPrefix = "exec:"
+
+// New creates a bot by name, using r for its randomness or a source seeded
+// from the clock if r is nil. Engines started by New have to be closed when
+// they are done.
+func New(name string, r *rand.Rand) (Player, error) {
+	if strings.HasPrefix(name, ExecPrefix) {
+		args := strings.Fields(strings.TrimPrefix(name, ExecPrefix))
+		if len(args) == 0 {
+			return nil, ErrUnknownBot
+		}
+
+		cmd := exec.Command(args[0], args[1:]...)
+		cmd.Stderr = os.Stderr
+		e, err := StartEngine(cmd)
+		if err != nil {
+			return nil, err
+		}
+		return e, nil
+	}
+
+	if r == nil {
+		r = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+
+	switch strings.ToLower(name) {
+	case "random":
+		return &Random{Rand: r}, nil
+	case "greedy":
+		return Greedy{}, nil
+	case "easy":
+		return &Strong{Level: Easy, Rand: r}, nil
+	case "medium":
+		return &Strong{Level: Medium, Rand: r}, nil
+	case "hard":
+		return &Strong{Level: Hard, Rand: r}, nil
+	}
+
+	return nil, ErrUnknownBot
+}
+
+// Names lists the kinds of bots New can create, besides engines.
+func Names() []string {
+	return []string{"easy", "greedy", "hard", "medium", "random"}
+}
+
+// Random plays a uniformly random legal move.
+type Random struct {
+	Rand *rand.Rand
+}
+
+// Move implements Player.
+func (r *Random) Move(ctx context.Context, v *game.View) (game.Event, error) {
+	if len(v.Moves) == 0 {
+		return game.Event{}, ErrNoMoves
+	}
+
+	return v.Moves[r.Rand.Intn(len(v.Moves))], nil
+}
+
+// Greedy always knocks when it can and otherwise gets rid of its heaviest
+// tile, satisfying open doubles first. It only draws when it can't play.
+type Greedy struct{}
+
+// Move implements Player.
+func (Greedy) Move(ctx context.Context, v *game.View) (game.Event, error) {
+	if len(v.Moves) == 0 {
+		return game.Event{}, ErrNoMoves
+	}
+
+	moves := append([]game.Event(nil), v.Moves...)
+	sort.SliceStable(moves, func(i, j int) bool {
+		return greedyRank(v, moves[i]) > greedyRank(v, moves[j])
+	})
+
+	return moves[0], nil
+}
+
+// greedyRank scores how much Greedy wants to make a move.
+func greedyRank(v *game.View, e game.Event) int {
+	switch e.Action {
+	case game.Knock:
+		return 1 << 20
+	case game.PlayDomino:
+		rank := 1<<10 + v.Hand[e.HandIndex].Value()
+		if v.Trains[e.PathID].UnresolvedDouble {
+			rank += 1 << 15
+		}
+		return rank
+	case game.DrawDomino:
+		return 2
+	case game.EndTurn:
+		return 1
+	}
+
+	return 0
+}
+
+// Table seats bots at a game.
+type Table struct {
+	// Seats maps player IDs to the bots playing for them.
+	Seats map[string]Player
+
+	// Fallback plays for seats handed to a bot in the middle of a game
+	// that have no bot in Seats. Greedy is used if it is nil.
+	Fallback Player
+
+	// Watch, if set, is told about every move a bot makes and what the game
+	// made of it.
+	Watch func(e game.Event, r *game.Response, err error)
+}
+
+// Close closes every bot in Seats that needs closing, such as engines.
+func (t *Table) Close() error {
+	var result error
+	for _, p := range t.Seats {
+		if c, ok := p.(io.Closer); ok {
+			if err := c.Close(); err != nil && result == nil {
+				result = err
+			}
+		}
+	}
+
+	return result
+}
+
+// bot returns who plays for the given player, or nil if a human does.
+func (t *Table) bot(g *game.Game, id string) Player {
+	if p, ok := t.Seats[id]; ok {
+		return p
+	}
+
+	if g.SeatOf(id) == game.Bot {
+		if t.Fallback != nil {
+			return t.Fallback
+		}
+		return Greedy{}
+	}
+
+	return nil
+}
+
+// Play lets bots take their turns for as long as it is a bot's turn, so that
+// a frontend only has to call it after every human move. It returns every
+// response for the frontend to pass on.
+func (t *Table) Play(ctx context.Context, g *game.Game) ([]*game.Response, error) {
+	var (
+		result  []*game.Response
+		refused int
+	)
+
+	for {
+		switch g.Phase {
+		case game.BigTurn, game.Playing:
+		default:
+			return result, nil
+		}
+
+		id := g.GetActivePlayer().ID
+		bot := t.bot(g, id)
+		if bot == nil {
+			return result, nil
+		}
+
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		v, err := g.View(id)
+		if err != nil {
+			return result, err
+		}
+
+		e, err := bot.Move(ctx, v)
+		if err != nil {
+			return result, err
+		}
+		e.PlayerID = id
+
+		r, err := g.HandleEvent(&e)
+		if t.Watch != nil {
+			t.Watch(e, r, err)
+		}
+
+		switch {
+		case err == nil && r.Success, err == game.ErrEndOfTurn:
+			refused = 0
+		default:
+			// The game said no. Bots are handed only legal moves, so this
+			// is a bug in the bot, but don't spin on it forever.
+			refused++
+			if refused > 10 {
+				return result, ErrStuck
+			}
+		}
+
+		if r != nil {
+			result = append(result, r)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package ai
+
+import (
+	"context"
+	"math/rand"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/cetacean/magiism/dominos"
+	"github.com/cetacean/magiism/dominos/game"
+)
+
+// playMatch has bots play a whole match against each other.
+func playMatch(t *testing.T, bots map[string]Player) *game.Game {
+	var players []string
+	for id := range bots {
+		players = append(players, id)
+	}
+
+	g, err := game.New(players)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	table := &Table{Seats: bots}
+	for turns := 0; g.Phase != game.MatchOver; turns++ {
+		if turns > 10000 {
+			t.Fatalf("match never ended:\n%s", g.Board())
+		}
+
+		_, err := table.Play(context.Background(), g)
+		if err != nil {
+			t.Fatalf("%v\n%s", err, g.Board())
+		}
+
+		if g.Phase == game.RoundOver {
+			err = g.NextRound()
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	return g
+}
+
+func TestBotsPlayAMatch(t *testing.T) {
+	r := rand.New(rand.NewSource(42))
+
+	for i := 0; i < 10; i++ {
+		g := playMatch(t, map[string]Player{
+			"Xena":      &Random{Rand: r},
+			"Vic":       Greedy{},
+			"Gabrielle": Greedy{},
+		})
+
+		t.Logf("final scores: %v", g.Scores)
+	}
+}
+
+func TestGreedy(t *testing.T) {
+	v := &game.View{
+		PlayerID: "Vic",
+		Hand:     nil,
+	}
+	if _, err := (Greedy{}).Move(context.Background(), v); err != ErrNoMoves {
+		t.Fatalf("wanted %v, got %v", ErrNoMoves, err)
+	}
+
+	g, err := game.New([]string{"Xena", "Vic"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v, err = g.View(g.GetActivePlayer().ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e, err := Greedy{}.Move(context.Background(), v)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, m := range v.Moves {
+		if m.Action == game.PlayDomino && e.Action != game.PlayDomino {
+			t.Fatalf("greedy should play when it can, but picked %v", e)
+		}
+
+		if m.Action == game.PlayDomino && v.Hand[m.HandIndex].Value() > v.Hand[e.HandIndex].Value() {
+			t.Fatalf("greedy played %s over %s", v.Hand[e.HandIndex].Display(), v.Hand[m.HandIndex].Display())
+		}
+	}
+}
+
+func TestStrongPlaysAMatch(t *testing.T) {
+	r := rand.New(rand.NewSource(42))
+
+	g := playMatch(t, map[string]Player{
+		"Xena":      &Strong{Level: Easy, Rand: r},
+		"Vic":       &Strong{Level: Medium, Rand: r, Budget: 2 * time.Millisecond},
+		"Gabrielle": Greedy{},
+	})
+
+	t.Logf("final scores: %v", g.Scores)
+}
+
+func TestLongestChain(t *testing.T) {
+	hand := []dominos.Domino{
+		{Left: 5, Right: 1},
+		{Left: 12, Right: 3},
+		{Left: 3, Right: 3},
+		{Left: 3, Right: 5},
+		{Left: 12, Right: 9},
+		{Left: 9, Right: 9},
+		{Left: 1, Right: 1},
+	}
+
+	// Neither the 9 nor the 1 double can end a chain, the 3 double has to go
+	// in the middle.
+	want := []int{1, 2, 3, 0}
+	got := longestChain(hand, 12)
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("wanted %v, got %v", want, got)
+	}
+
+	if got := longestChain(hand, 7); len(got) != 0 {
+		t.Fatalf("nothing in the hand starts with a 7, got %v", got)
+	}
+}
@@ -0,0 +1,24 @@
+package ai
+
+import (
+	"github.com/cetacean/magiism/dominos"
+)
+
+// longestChain finds the longest train that can be built out of the hand
+// starting from the pip start, and returns indexes into the hand in the order
+// the tiles are to be played.
+func longestChain(hand []dominos.Domino, start int) []int {
+	train, _ := dominos.LongestTrain(hand, start, dominos.MostTiles)
+
+	var result []int
+	for _, d := range train {
+		for i, h := range hand {
+			if h == d || h == (dominos.Domino{Left: d.Right, Right: d.Left}) {
+				result = append(result, i)
+				break
+			}
+		}
+	}
+
+	return result
+}
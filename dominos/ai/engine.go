@@ -0,0 +1,253 @@
+package ai
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/cetacean/magiism/dominos/game"
+)
+
+// ProtocolVersion is the version of the engine protocol spoken by Engine.
+const ProtocolVersion = 1
+
+// ErrEngineHandshake is returned when an engine doesn't start up properly.
+var ErrEngineHandshake = errors.New("ai: engine did not say it was ready")
+
+// handshakeTimeout is how long an engine has to start up.
+var handshakeTimeout = 10 * time.Second
+
+// DefaultEngineTimeout is how long an engine may think about a move when its
+// Timeout isn't set.
+const DefaultEngineTimeout = 5 * time.Second
+
+// Engine plays moves chosen by another program, so that bots can be written
+// in any language. Engines read messages from stdin and write messages to
+// stdout, one JSON object per line, each with a "type":
+//
+// When it starts, the engine is sent
+//
+//	{"type":"hello","protocol":1}
+//
+// and has to answer with
+//
+//	{"type":"ready","name":"Some Bot"}
+//
+// Whenever it is the engine's turn, it is sent its view of the game, as
+// encoded by encoding/json from game.View, along with how long it has to
+// answer in milliseconds:
+//
+//	{"type":"move","id":7,"time_ms":5000,"view":{...}}
+//
+// The view's Moves lists every legal move. The engine answers with the index
+// of the one it wants to make and the same id:
+//
+//	{"type":"move","id":7,"move":2}
+//
+// If it doesn't answer in time, answers with a move that isn't in the list
+// or quits, the Fallback bot moves for it instead and any late answer is
+// ignored. Engines may also send {"type":"info","info":"..."} lines at any
+// time, which are ignored. Finally, when the engine is no longer needed it
+// is sent {"type":"quit"} and should exit.
+type Engine struct {
+	// Name is what the engine calls itself.
+	Name string
+
+	// Timeout is how long the engine may think about a move, or
+	// DefaultEngineTimeout if zero.
+	Timeout time.Duration
+
+	// Fallback moves when the engine doesn't. Greedy is used if it is nil.
+	Fallback Player
+
+	// Misses counts the moves Fallback had to make.
+	Misses int
+
+	cmd  *exec.Cmd
+	in   io.WriteCloser
+	enc  *json.Encoder
+	msgs chan engineMsg
+	next int
+
+	closeOnce sync.Once
+}
+
+// engineMsg is a single line of the engine protocol.
+type engineMsg struct {
+	Type     string     `json:"type"`
+	ID       int        `json:"id,omitempty"`
+	Protocol int        `json:"protocol,omitempty"`
+	Name     string     `json:"name,omitempty"`
+	TimeMS   int64      `json:"time_ms,omitempty"`
+	View     *game.View `json:"view,omitempty"`
+	Move     *int       `json:"move,omitempty"`
+	Info     string     `json:"info,omitempty"`
+}
+
+// StartEngine starts the given command and waits for it to say it is ready.
+// The command's stdin and stdout must not be set.
+func StartEngine(cmd *exec.Cmd) (*Engine, error) {
+	in, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	err = cmd.Start()
+	if err != nil {
+		return nil, err
+	}
+
+	e := &Engine{
+		cmd:  cmd,
+		in:   in,
+		enc:  json.NewEncoder(in),
+		msgs: make(chan engineMsg, 16),
+	}
+	go e.read(out)
+
+	err = e.enc.Encode(engineMsg{Type: "hello", Protocol: ProtocolVersion})
+	if err != nil {
+		e.Close()
+		return nil, err
+	}
+
+	timer := time.NewTimer(handshakeTimeout)
+	defer timer.Stop()
+
+	for {
+		select {
+		case m, ok := <-e.msgs:
+			if !ok {
+				e.Close()
+				return nil, ErrEngineHandshake
+			}
+
+			if m.Type == "ready" {
+				e.Name = m.Name
+				return e, nil
+			}
+
+		case <-timer.C:
+			e.Close()
+			return nil, ErrEngineHandshake
+		}
+	}
+}
+
+// read passes on every message the engine writes, skipping lines that
+// aren't messages, until it quits.
+func (e *Engine) read(out io.Reader) {
+	defer close(e.msgs)
+
+	scanner := bufio.NewScanner(out)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		var m engineMsg
+		if json.Unmarshal(scanner.Bytes(), &m) != nil {
+			continue
+		}
+
+		e.msgs <- m
+	}
+}
+
+// Move implements Player.
+func (e *Engine) Move(ctx context.Context, v *game.View) (game.Event, error) {
+	if len(v.Moves) == 0 {
+		return game.Event{}, ErrNoMoves
+	}
+
+	timeout := e.Timeout
+	if timeout <= 0 {
+		timeout = DefaultEngineTimeout
+	}
+
+	e.next++
+	id := e.next
+
+	err := e.enc.Encode(engineMsg{
+		Type:   "move",
+		ID:     id,
+		TimeMS: int64(timeout / time.Millisecond),
+		View:   v,
+	})
+	if err != nil {
+		return e.fallback(ctx, v)
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		select {
+		case m, ok := <-e.msgs:
+			if !ok {
+				return e.fallback(ctx, v)
+			}
+
+			// Anything else is chatter or an answer that came too late.
+			if m.Type != "move" || m.ID != id {
+				continue
+			}
+
+			if m.Move == nil || *m.Move < 0 || *m.Move >= len(v.Moves) {
+				return e.fallback(ctx, v)
+			}
+
+			return v.Moves[*m.Move], nil
+
+		case <-timer.C:
+			return e.fallback(ctx, v)
+
+		case <-ctx.Done():
+			return game.Event{}, ctx.Err()
+		}
+	}
+}
+
+// fallback has the fallback bot move in the engine's place.
+func (e *Engine) fallback(ctx context.Context, v *game.View) (game.Event, error) {
+	e.Misses++
+
+	if e.Fallback != nil {
+		return e.Fallback.Move(ctx, v)
+	}
+	return Greedy{}.Move(ctx, v)
+}
+
+// Close tells the engine to quit and makes sure it does.
+func (e *Engine) Close() error {
+	e.closeOnce.Do(func() {
+		e.enc.Encode(engineMsg{Type: "quit"})
+		e.in.Close()
+
+		exited := make(chan struct{})
+		go func() {
+			// Drain whatever is left so the engine never blocks on
+			// writing.
+			for range e.msgs {
+			}
+			e.cmd.Wait()
+			close(exited)
+		}()
+
+		select {
+		case <-exited:
+		case <-time.After(time.Second):
+			e.cmd.Process.Kill()
+			<-exited
+		}
+	})
+
+	return nil
+}
@@ -0,0 +1,148 @@
+package ai
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/cetacean/magiism/dominos/game"
+)
+
+// helperEngine starts this test binary as an engine that behaves according
+// to mode.
+func helperEngine(t *testing.T, mode string) (*Engine, error) {
+	cmd := exec.Command(os.Args[0], "-test.run=TestHelperEngine")
+	cmd.Env = append(os.Environ(), "WANT_HELPER_ENGINE="+mode)
+	cmd.Stderr = os.Stderr
+
+	return StartEngine(cmd)
+}
+
+// TestHelperEngine isn't a real test, it is the engine run by helperEngine.
+// It always makes the first move it is given, unless its mode says
+// otherwise:
+//
+//	mute: never says it is ready
+//	slow: never answers
+//	bad:  makes moves that don't exist
+func TestHelperEngine(t *testing.T) {
+	mode, ok := os.LookupEnv("WANT_HELPER_ENGINE")
+	if !ok {
+		return
+	}
+	defer os.Exit(0)
+
+	var msg struct {
+		Type string `json:"type"`
+		ID   int    `json:"id"`
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			fmt.Fprintf(os.Stderr, "engine: %v\n", err)
+			os.Exit(1)
+		}
+
+		switch msg.Type {
+		case "hello":
+			if mode != "mute" {
+				fmt.Println("this isn't JSON")
+				fmt.Println(`{"type":"ready","name":"Helper"}`)
+			}
+		case "move":
+			fmt.Println(`{"type":"info","info":"thinking"}`)
+			switch mode {
+			case "slow":
+			case "bad":
+				fmt.Printf(`{"type":"move","id":%d,"move":99}`+"\n", msg.ID)
+			default:
+				fmt.Printf(`{"type":"move","id":%d,"move":0}`+"\n", msg.ID)
+			}
+		case "quit":
+			return
+		}
+	}
+}
+
+func TestEnginePlaysAMatch(t *testing.T) {
+	e, err := helperEngine(t, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer e.Close()
+
+	if e.Name != "Helper" {
+		t.Fatalf("wanted the engine to be called Helper, got %q", e.Name)
+	}
+
+	g := playMatch(t, map[string]Player{
+		"Xena": e,
+		"Vic":  Greedy{},
+	})
+	if e.Misses != 0 {
+		t.Fatalf("engine missed %d moves", e.Misses)
+	}
+
+	t.Logf("final scores: %v", g.Scores)
+}
+
+func TestEngineFallback(t *testing.T) {
+	g, err := game.New([]string{"Xena", "Vic"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v, err := g.View(g.GetActivePlayer().ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want, err := Greedy{}.Move(context.Background(), v)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, mode := range []string{"slow", "bad"} {
+		e, err := helperEngine(t, mode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		e.Timeout = 100 * time.Millisecond
+
+		for i := 1; i <= 2; i++ {
+			got, err := e.Move(context.Background(), v)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != want || e.Misses != i {
+				t.Fatalf("%s engine: wanted %v with %d misses, got %v with %d", mode, want, i, got, e.Misses)
+			}
+		}
+
+		e.Close()
+	}
+}
+
+func TestEngineHandshake(t *testing.T) {
+	defer func(old time.Duration) { handshakeTimeout = old }(handshakeTimeout)
+	handshakeTimeout = 100 * time.Millisecond
+
+	_, err := helperEngine(t, "mute")
+	if err != ErrEngineHandshake {
+		t.Fatalf("wanted %v, got %v", ErrEngineHandshake, err)
+	}
+}
+
+func TestNewEngineMissing(t *testing.T) {
+	p, err := New(ExecPrefix+"/there/is/no/such/engine", nil)
+	if err == nil || p != nil {
+		t.Fatalf("wanted no bot and an error, got %#v and %v", p, err)
+	}
+}
@@ -0,0 +1,222 @@
+package ai
+
+import (
+	"math/rand"
+
+	"github.com/cetacean/magiism/dominos/game"
+	"github.com/cetacean/magiism/dominos/internal/compact"
+)
+
+// maxPlayoutTurns gives up on playouts that somehow never end.
+const maxPlayoutTurns = 1000
+
+// sim plays a round out on a compact board. It follows the turn rules of
+// package game, boiled down to what matters for how a round ends: a turn is
+// one tile, or more to satisfy doubles, a player who can't play draws once
+// and puts their train up if that didn't help, and nobody forgets to knock.
+type sim struct {
+	g      *compact.Game
+	r      *rand.Rand
+	own    []int  // Index of every seat's own path.
+	seated []bool // Seats that take turns.
+	passes int
+	turns  int
+
+	moves []compact.Move // Scratch space.
+	tiles []compact.Tile
+}
+
+func newSim(g *compact.Game, r *rand.Rand, v *game.View) *sim {
+	s := &sim{
+		g:      g,
+		r:      r,
+		own:    make([]int, len(v.Players)),
+		seated: make([]bool, len(v.Players)),
+		passes: v.Passes,
+	}
+
+	for i, pv := range v.Players {
+		s.own[i] = pv.Path
+		s.seated[i] = pv.Seat != game.Vacant && pv.Seat != game.Gone
+	}
+
+	return s
+}
+
+// pick chooses a tile for the seat to play: the heaviest one, but taking its
+// own train down first and keeping doubles it can't satisfy.
+func (s *sim) pick(seat int) (compact.Move, bool) {
+	s.moves = s.g.Moves(s.moves[:0], seat)
+	if len(s.moves) == 0 {
+		return compact.Move{}, false
+	}
+
+	hand := s.g.Hands[seat]
+	up := s.own[seat] >= 0 && s.g.Paths[s.own[seat]].Train
+
+	best, bestScore := 0, -1<<30
+	for i, m := range s.moves {
+		score := m.Tile.Value()
+		if up && int(m.Path) == s.own[seat] {
+			score += 100
+		}
+		if m.Tile.IsDouble() && hand.With(m.Tile.Domino().Left).Len() < 2 {
+			score -= 50
+		}
+
+		if score > bestScore {
+			best, bestScore = i, score
+		}
+	}
+
+	return s.moves[best], true
+}
+
+// draw gives the seat a random tile from the boneyard.
+func (s *sim) draw(seat int) {
+	s.tiles = s.g.Pool.Tiles(s.tiles[:0])
+	s.g.Draw(seat, s.tiles[s.r.Intn(len(s.tiles))])
+}
+
+// turn finishes the active seat's turn from where it is and passes it on.
+// It returns false once the round is over.
+func (s *sim) turn(drawn, played bool) bool {
+	seat := s.g.Active
+
+	for {
+		m, ok := s.pick(seat)
+		if ok {
+			s.g.Play(seat, m)
+			played = true
+
+			if s.g.Hands[seat].Len() == 0 {
+				return false
+			}
+
+			// A double has to be followed by another tile.
+			if m.Tile.IsDouble() {
+				played = false
+				continue
+			}
+			break
+		}
+
+		if !drawn && s.g.Pool.Len() > 0 {
+			s.draw(seat)
+			drawn = true
+			continue
+		}
+
+		break
+	}
+
+	return s.end(played)
+}
+
+// end ends the active seat's turn and hands it to the next seat. It returns
+// false once the round is over.
+func (s *sim) end(played bool) bool {
+	seat := s.g.Active
+	if !played {
+		if s.own[seat] >= 0 {
+			s.g.Paths[s.own[seat]].Train = true
+		}
+		s.passes++
+	} else {
+		s.passes = 0
+	}
+
+	seated := 0
+	for _, ok := range s.seated {
+		if ok {
+			seated++
+		}
+	}
+	if seated == 0 || (s.g.Pool.Len() == 0 && s.passes >= seated) {
+		return false
+	}
+
+	s.turns++
+	if s.turns > maxPlayoutTurns {
+		return false
+	}
+
+	for i := 1; i <= len(s.seated); i++ {
+		next := (seat + i) % len(s.seated)
+		if s.seated[next] {
+			s.g.Active = next
+			break
+		}
+	}
+
+	return true
+}
+
+// playout makes the move on the made up board and plays out the rest of the
+// round for everyone. It returns how many pips the viewer ended up ahead of
+// the average opponent, or false if the round couldn't be finished.
+func playout(v *game.View, root *compact.Game, e game.Event, r *rand.Rand) (float64, bool) {
+	g := root.Clone()
+	s := newSim(g, r, v)
+	me := v.Active
+
+	var alive bool
+	switch e.Action {
+	case game.PlayDomino:
+		t := compact.ID(v.Hand[e.HandIndex])
+		g.Play(me, compact.Move{Tile: t, Path: int8(e.PathID)})
+
+		switch {
+		case g.Hands[me].Len() == 0:
+			alive = false
+		case t.IsDouble():
+			alive = s.turn(v.Drawn, false)
+		default:
+			alive = s.end(true)
+		}
+
+	case game.DrawDomino:
+		if g.Pool.Len() == 0 {
+			return 0, false
+		}
+		s.draw(me)
+		alive = s.turn(true, v.Played)
+
+	case game.EndTurn:
+		alive = s.end(v.Played)
+
+	case game.Knock:
+		g.Knocked |= 1 << uint(me)
+		alive = s.turn(v.Drawn, v.Played)
+
+	default:
+		return 0, false
+	}
+
+	for alive {
+		alive = s.turn(false, false)
+	}
+	if s.turns > maxPlayoutTurns {
+		return 0, false
+	}
+
+	var (
+		mine, others float64
+		opponents    int
+	)
+	for i, hand := range g.Hands {
+		switch {
+		case i == me:
+			mine = float64(hand.Value())
+		case v.Players[i].Seat != game.Gone:
+			others += float64(hand.Value())
+			opponents++
+		}
+	}
+
+	if opponents > 0 {
+		others /= float64(opponents)
+	}
+
+	return others - mine, true
+}
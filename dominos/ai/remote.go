@@ -0,0 +1,299 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cetacean/magiism/dominos/game"
+)
+
+// SignatureHeader is the header a Remote signs its requests in, with the hex
+// encoded HMAC-SHA256 of the body under the bot's secret.
+const SignatureHeader = "X-Magiism-Signature"
+
+// Remote defaults
+const (
+	DefaultRemoteTimeout = 5 * time.Second
+	DefaultRemoteRetries = 2
+)
+
+// errRetry marks failures that are worth trying again.
+var errRetry = errors.New("ai: remote bot had a hiccup")
+
+// Remote plays moves chosen by a bot reachable over HTTP. Whenever it is the
+// bot's turn, the view of the game is POSTed to its URL in the same message
+// Engine sends on a move:
+//
+//	{"type":"move","id":7,"time_ms":5000,"view":{...}}
+//
+// signed in SignatureHeader if the bot has a secret, so the bot can tell the
+// request came from us. The bot answers with the index of the move it wants
+// to make in the view's Moves:
+//
+//	{"type":"move","id":7,"move":2}
+//
+// Requests that fail or get a 5xx back are retried until the time runs out.
+// If there is no usable answer by then, the Fallback bot moves instead.
+type Remote struct {
+	URL    string
+	Secret string
+
+	// Timeout is how long the bot has to answer, retries included, or
+	// DefaultRemoteTimeout if zero.
+	Timeout time.Duration
+
+	// Retries is how many times a failed request is tried again, or
+	// DefaultRemoteRetries if zero. Negative values turn retries off.
+	Retries int
+
+	// Fallback moves when the bot doesn't. Greedy is used if it is nil.
+	Fallback Player
+
+	// Client makes the requests, or http.DefaultClient if nil.
+	Client *http.Client
+
+	// Misses counts the moves Fallback had to make.
+	Misses int
+
+	next int
+}
+
+// Sign returns the signature of body under secret, as sent in
+// SignatureHeader.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Move implements Player.
+func (r *Remote) Move(ctx context.Context, v *game.View) (game.Event, error) {
+	if len(v.Moves) == 0 {
+		return game.Event{}, ErrNoMoves
+	}
+
+	timeout := r.Timeout
+	if timeout <= 0 {
+		timeout = DefaultRemoteTimeout
+	}
+
+	retries := r.Retries
+	if retries == 0 {
+		retries = DefaultRemoteRetries
+	}
+
+	r.next++
+	id := r.next
+
+	body, err := json.Marshal(engineMsg{
+		Type:   "move",
+		ID:     id,
+		TimeMS: int64(timeout / time.Millisecond),
+		View:   v,
+	})
+	if err != nil {
+		return game.Event{}, err
+	}
+
+	deadline, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	for try := 0; try <= retries; try++ {
+		if try > 0 {
+			select {
+			case <-time.After(time.Duration(try) * 100 * time.Millisecond):
+			case <-deadline.Done():
+			}
+		}
+		if deadline.Err() != nil {
+			break
+		}
+
+		var m *engineMsg
+		m, err = r.post(deadline, body)
+		if err == nil {
+			if m.Type != "move" || m.ID != id || m.Move == nil || *m.Move < 0 || *m.Move >= len(v.Moves) {
+				break
+			}
+			return v.Moves[*m.Move], nil
+		}
+
+		if err != errRetry && deadline.Err() == nil {
+			break
+		}
+	}
+
+	// Only give up on the move if we were asked to, not if the bot was
+	// too slow.
+	if ctx.Err() != nil {
+		return game.Event{}, ctx.Err()
+	}
+
+	r.Misses++
+	if r.Fallback != nil {
+		return r.Fallback.Move(ctx, v)
+	}
+	return Greedy{}.Move(ctx, v)
+}
+
+// post sends a single request to the bot.
+func (r *Remote) post(ctx context.Context, body []byte) (*engineMsg, error) {
+	req, err := http.NewRequest("POST", r.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	if r.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(r.Secret, body))
+	}
+
+	client := r.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, errRetry
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 500:
+		return nil, errRetry
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("ai: remote bot said %s", resp.Status)
+	}
+
+	m := &engineMsg{}
+	err = json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(m)
+	if err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// RemoteBot is how to reach a bot over HTTP.
+type RemoteBot struct {
+	URL       string `json:"url"`
+	Secret    string `json:"secret,omitempty"`
+	TimeoutMS int64  `json:"timeout_ms,omitempty"`
+}
+
+// Registry keeps track of the remote bots each guild has set up, so that
+// every guild can bring its own opponents without sharing their secrets.
+// It is safe for concurrent use.
+type Registry struct {
+	mu     sync.Mutex
+	guilds map[string]map[string]RemoteBot
+}
+
+// NewRegistry creates an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{guilds: map[string]map[string]RemoteBot{}}
+}
+
+// LoadRegistry reads a Registry saved with Save from a file. A file that
+// doesn't exist is an empty registry.
+func LoadRegistry(fname string) (*Registry, error) {
+	reg := NewRegistry()
+
+	data, err := ioutil.ReadFile(fname)
+	if os.IsNotExist(err) {
+		return reg, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(data, &reg.guilds)
+	if err != nil {
+		return nil, err
+	}
+	if reg.guilds == nil {
+		reg.guilds = map[string]map[string]RemoteBot{}
+	}
+
+	return reg, nil
+}
+
+// Save writes the registry to a file, which holds secrets and so is only
+// readable by its owner.
+func (reg *Registry) Save(fname string) error {
+	reg.mu.Lock()
+	data, err := json.MarshalIndent(reg.guilds, "", "  ")
+	reg.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(fname, data, 0600)
+}
+
+// Set adds a bot to a guild, replacing any bot it had by that name.
+func (reg *Registry) Set(guild, name string, b RemoteBot) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	if reg.guilds[guild] == nil {
+		reg.guilds[guild] = map[string]RemoteBot{}
+	}
+	reg.guilds[guild][name] = b
+}
+
+// Remove takes a bot away from a guild.
+func (reg *Registry) Remove(guild, name string) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	delete(reg.guilds[guild], name)
+	if len(reg.guilds[guild]) == 0 {
+		delete(reg.guilds, guild)
+	}
+}
+
+// Names lists the bots a guild has set up.
+func (reg *Registry) Names(guild string) []string {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	var result []string
+	for name := range reg.guilds[guild] {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+
+	return result
+}
+
+// Bot creates a player for one of a guild's bots.
+func (reg *Registry) Bot(guild, name string) (*Remote, error) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	b, ok := reg.guilds[guild][name]
+	if !ok {
+		return nil, ErrUnknownBot
+	}
+
+	return &Remote{
+		URL:     b.URL,
+		Secret:  b.Secret,
+		Timeout: time.Duration(b.TimeoutMS) * time.Millisecond,
+	}, nil
+}
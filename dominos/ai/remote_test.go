@@ -0,0 +1,187 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cetacean/magiism/dominos/game"
+)
+
+// standIn is a remote bot that checks requests are signed with secret and
+// makes the first move it is given, after failing as many times as it is
+// told to.
+type standIn struct {
+	secret string
+	fail   int           // Requests to answer with a 500 first.
+	delay  time.Duration // How long to take over every answer.
+	move   int
+
+	mu       sync.Mutex
+	requests int
+}
+
+// count returns how many requests the bot has had.
+func (s *standIn) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+func (s *standIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests++
+	failing := s.requests <= s.fail
+	s.mu.Unlock()
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if r.Header.Get(SignatureHeader) != Sign(s.secret, body) {
+		http.Error(w, "bad signature", http.StatusUnauthorized)
+		return
+	}
+
+	if failing {
+		http.Error(w, "try again", http.StatusInternalServerError)
+		return
+	}
+
+	var msg struct {
+		ID   int        `json:"id"`
+		View *game.View `json:"view"`
+	}
+	if err := json.Unmarshal(body, &msg); err != nil || msg.View == nil {
+		http.Error(w, "bad view", http.StatusBadRequest)
+		return
+	}
+
+	time.Sleep(s.delay)
+	fmt.Fprintf(w, `{"type":"move","id":%d,"move":%d}`, msg.ID, s.move)
+}
+
+func TestRemotePlaysAMatch(t *testing.T) {
+	bot := &standIn{secret: "hunter2"}
+	srv := httptest.NewServer(bot)
+	defer srv.Close()
+
+	r := &Remote{URL: srv.URL, Secret: "hunter2"}
+	g := playMatch(t, map[string]Player{
+		"Xena": r,
+		"Vic":  Greedy{},
+	})
+	if r.Misses != 0 {
+		t.Fatalf("remote bot missed %d moves", r.Misses)
+	}
+
+	t.Logf("final scores after %d requests: %v", bot.count(), g.Scores)
+}
+
+func TestRemoteFallback(t *testing.T) {
+	g, err := game.New([]string{"Xena", "Vic"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v, err := g.View(g.GetActivePlayer().ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	greedy, err := Greedy{}.Move(context.Background(), v)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name     string
+		bot      *standIn
+		secret   string
+		misses   int
+		requests int
+	}{
+		{name: "fine", bot: &standIn{secret: "s"}, secret: "s", requests: 1},
+		{name: "flaky", bot: &standIn{secret: "s", fail: 2}, secret: "s", requests: 3},
+		{name: "down", bot: &standIn{secret: "s", fail: 10}, secret: "s", misses: 1, requests: 3},
+		{name: "wrong secret", bot: &standIn{secret: "s"}, secret: "x", misses: 1, requests: 1},
+		{name: "slow", bot: &standIn{secret: "s", delay: time.Second}, secret: "s", misses: 1, requests: 1},
+		{name: "bad move", bot: &standIn{secret: "s", move: 99}, secret: "s", misses: 1, requests: 1},
+	}
+
+	for _, c := range cases {
+		srv := httptest.NewServer(c.bot)
+
+		r := &Remote{URL: srv.URL, Secret: c.secret, Timeout: 500 * time.Millisecond}
+		got, err := r.Move(context.Background(), v)
+		srv.Close()
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+
+		want := v.Moves[0]
+		if c.misses > 0 {
+			want = greedy
+		}
+		if got != want || r.Misses != c.misses || c.bot.count() != c.requests {
+			t.Fatalf("%s: wanted %v after %d requests with %d misses, got %v after %d with %d",
+				c.name, want, c.requests, c.misses, got, c.bot.count(), r.Misses)
+		}
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	dir, err := ioutil.TempDir("", "registry")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	fname := filepath.Join(dir, "bots.json")
+
+	reg, err := LoadRegistry(fname)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reg.Set("amazons", "ares", RemoteBot{URL: "http://ares.example/move", Secret: "war", TimeoutMS: 2000})
+	reg.Set("amazons", "hades", RemoteBot{URL: "http://hades.example/move"})
+	reg.Set("centaurs", "ares", RemoteBot{URL: "http://other.example/move"})
+	reg.Remove("centaurs", "ares")
+
+	err = reg.Save(fname)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reg, err = LoadRegistry(fname)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if names := reg.Names("amazons"); !reflect.DeepEqual(names, []string{"ares", "hades"}) {
+		t.Fatalf("wrong bots: %v", names)
+	}
+
+	if _, err := reg.Bot("centaurs", "ares"); err != ErrUnknownBot {
+		t.Fatalf("wanted %v, got %v", ErrUnknownBot, err)
+	}
+
+	r, err := reg.Bot("amazons", "ares")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.URL != "http://ares.example/move" || r.Secret != "war" || r.Timeout != 2*time.Second {
+		t.Fatalf("wrong bot: %+v", r)
+	}
+}
@@ -0,0 +1,276 @@
+package ai
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"github.com/cetacean/magiism/dominos"
+	"github.com/cetacean/magiism/dominos/game"
+	"github.com/cetacean/magiism/dominos/internal/compact"
+)
+
+// Level is how hard a Strong bot tries.
+type Level int
+
+// Possible levels for a Strong bot.
+const (
+	// Easy plays its longest chain and nothing more.
+	Easy Level = iota
+
+	// Medium and Hard also look ahead by playing the rest of the round out
+	// many times over, with a different guess at everyone's hands each
+	// time. Hard does so for longer.
+	Medium
+	Hard
+)
+
+// String implements fmt.Stringer.
+func (l Level) String() string {
+	switch l {
+	case Easy:
+		return "easy"
+	case Medium:
+		return "medium"
+	case Hard:
+		return "hard"
+	}
+
+	return "unknown"
+}
+
+// How long Strong thinks and how many guesses it tries per move at each
+// level when not told otherwise.
+var (
+	levelBudgets = map[Level]time.Duration{
+		Medium: 250 * time.Millisecond,
+		Hard:   2 * time.Second,
+	}
+
+	levelSamples = map[Level]int{
+		Medium: 300,
+		Hard:   3000,
+	}
+)
+
+// Strong plans its own train as the longest chain it can make out of its
+// hand, keeps doubles back until it can satisfy them and, above Easy, weighs
+// its moves by playing out the rest of the round against guesses of what
+// everyone else holds.
+type Strong struct {
+	Level Level
+	Rand  *rand.Rand
+
+	// Budget is how long it may think about a single move. Zero uses the
+	// level's default.
+	Budget time.Duration
+}
+
+// Move implements Player.
+func (s *Strong) Move(ctx context.Context, v *game.View) (game.Event, error) {
+	if len(v.Moves) == 0 {
+		return game.Event{}, ErrNoMoves
+	}
+
+	// The big turn only allows playing on our own train, which the chain
+	// already plans.
+	if len(v.Moves) == 1 || s.Level == Easy || v.Phase != game.Playing {
+		return plan(v), nil
+	}
+
+	budget := s.Budget
+	if budget <= 0 {
+		budget = levelBudgets[s.Level]
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, budget)
+	defer cancel()
+
+	return s.search(ctx, v), nil
+}
+
+// plan picks a move from the longest chain through the hand: it takes its
+// train down with the chain if it is up, otherwise gets rid of the heaviest
+// tile that isn't part of the chain on someone else's train, and otherwise
+// goes on with the chain.
+func plan(v *game.View) game.Event {
+	me := v.Players[v.Active]
+	own := v.Trains[me.Path]
+
+	chain := longestChain(v.Hand, own.End(v.Center))
+	inChain := map[int]bool{}
+	for _, i := range chain {
+		inChain[i] = true
+	}
+
+	rank := func(e game.Event) int {
+		switch e.Action {
+		case game.Knock:
+			return 1 << 20
+		case game.DrawDomino:
+			return 2
+		case game.EndTurn:
+			return 1
+		case game.PlayDomino:
+		default:
+			return 0
+		}
+
+		d := v.Hand[e.HandIndex]
+		r := 1 << 10
+
+		switch {
+		case v.Trains[e.PathID].UnresolvedDouble:
+			r += 1<<15 + d.Value()
+		case e.PathID == me.Path && len(chain) > 0 && e.HandIndex == chain[0]:
+			r += 1 << 11
+			if own.Train {
+				r += 1 << 13
+			}
+		case e.PathID != me.Path && !inChain[e.HandIndex]:
+			r += 1<<12 + d.Value()
+		default:
+			r += d.Value()
+		}
+
+		// Save doubles for when there is a tile to satisfy them with.
+		if d.IsDouble() && !canSatisfy(v.Hand, e.HandIndex) {
+			r -= 1 << 9
+		}
+
+		return r
+	}
+
+	best := v.Moves[0]
+	for _, e := range v.Moves[1:] {
+		if rank(e) > rank(best) {
+			best = e
+		}
+	}
+
+	return best
+}
+
+// canSatisfy returns true if another tile in the hand can be played on the
+// double at hand[i].
+func canSatisfy(hand []dominos.Domino, i int) bool {
+	for j, d := range hand {
+		if j != i && (d.Left == hand[i].Left || d.Right == hand[i].Left) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// search plays every move out against many guesses of the hidden tiles,
+// using the same guesses for every move, and returns the move that left it
+// best off on average at the end of the round.
+func (s *Strong) search(ctx context.Context, v *game.View) game.Event {
+	totals := make([]float64, len(v.Moves))
+	counts := make([]int, len(v.Moves))
+
+	for n := 0; n < levelSamples[s.Level] && ctx.Err() == nil; n++ {
+		world, err := compact.FromGame(s.determinize(v))
+		if err != nil {
+			break
+		}
+
+		for i, e := range v.Moves {
+			score, ok := playout(v, world, e, s.Rand)
+			if !ok {
+				continue
+			}
+
+			totals[i] += score
+			counts[i]++
+		}
+	}
+
+	best := -1
+	for i := range v.Moves {
+		if counts[i] == 0 {
+			continue
+		}
+
+		if best < 0 || totals[i]/float64(counts[i]) > totals[best]/float64(counts[best]) {
+			best = i
+		}
+	}
+
+	if best < 0 {
+		return plan(v)
+	}
+
+	return v.Moves[best]
+}
+
+// determinize makes up a board consistent with what the view shows, dealing
+// the tiles the viewer can't see at random to everyone else and to the
+// boneyard.
+func (s *Strong) determinize(v *game.View) *dominos.Game {
+	seen := map[dominos.Domino]bool{
+		v.Center: true,
+	}
+	for _, path := range v.Trains {
+		for _, e := range path.Elements {
+			seen[e.Domino] = true
+		}
+	}
+	for _, d := range v.Hand {
+		seen[d] = true
+	}
+
+	var set []dominos.Domino
+	for i := 0; i <= v.Set; i++ {
+		for j := 0; j <= i; j++ {
+			d := dominos.Domino{Left: i, Right: j}
+			if !seen[d] && !seen[dominos.Domino{Left: j, Right: i}] {
+				set = append(set, d)
+			}
+		}
+	}
+
+	var unseen []dominos.Domino
+	for _, i := range s.Rand.Perm(len(set)) {
+		unseen = append(unseen, set[i])
+	}
+
+	dg := (&dominos.Game{
+		Trains:           v.Trains,
+		Center:           v.Center,
+		UnresolvedDouble: v.UnresolvedDouble,
+		ActivePlayer:     v.Active,
+	}).Clone()
+
+	for _, pv := range v.Players {
+		p := &dominos.Player{
+			ID:      pv.ID,
+			Knocked: pv.Knocked,
+			BigPlay: true,
+		}
+		if pv.Path >= 0 {
+			p.Path = dg.Trains[pv.Path]
+		}
+
+		if pv.ID == v.PlayerID {
+			p.Hand = append(p.Hand, v.Hand...)
+		} else {
+			n := pv.Tiles
+			if n > len(unseen) {
+				n = len(unseen)
+			}
+			p.Hand = append(p.Hand, unseen[:n]...)
+			unseen = unseen[n:]
+		}
+
+		dg.Players = append(dg.Players, p)
+	}
+
+	if len(unseen) > v.Pool {
+		unseen = unseen[:v.Pool]
+	}
+	dg.TilePool = unseen
+
+	return dg
+}
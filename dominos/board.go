@@ -0,0 +1,334 @@
+package dominos
+
+import (
+	"bufio"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// BoardError describes a problem found by ParseBoard.
+type BoardError struct {
+	Line int
+	Msg  string
+}
+
+func (e *BoardError) Error() string {
+	if e.Line == 0 {
+		return "domino: board: " + e.Msg
+	}
+	return fmt.Sprintf("domino: board line %d: %s", e.Line, e.Msg)
+}
+
+// Board renders the whole game as text that ParseBoard can read back. The
+// format is line based, blank lines and lines starting with # are ignored:
+//
+//	center [6||6]
+//	turn Vic
+//	    Xena >> [6|1] [1|4] *
+//	     Vic >> [6|3]
+//	       M >> [6|4] [4||4] <!>
+//	hand Xena: [5|5]
+//	hand Vic: [4|2] knocked
+//	pool: [3|0] [1|1]
+//
+// Path lines look like Path.Display: the owner (M for the Mexican train), >>,
+// and the tiles from the center outwards, each written the way it is seen so
+// that touching sides match. A trailing * means the path has a train on it,
+// <!> marks an unresolved double and (left) a path whose owner left the
+// game. Element indexes such as "0:[6|1]" are accepted, so Display output
+// can be pasted straight in.
+//
+// Players are seated in the order their paths are listed. The turn line
+// names the active player (the first player if missing), hand lines give
+// each player's tiles and the pool line lists the boneyard in draw order.
+func (g *Game) Board() string {
+	var lines []string
+
+	lines = append(lines, "center "+g.Center.Display())
+	if len(g.Players) > 0 {
+		lines = append(lines, "turn "+g.GetActivePlayer().ID)
+	}
+
+	for _, p := range g.Trains {
+		owner := p.Player
+		if p.MexicanTrain {
+			owner = "M"
+		}
+
+		line := fmt.Sprintf("%8s >>", owner)
+		for _, e := range p.Elements {
+			line += " " + e.Display()
+		}
+		if p.Train && !p.MexicanTrain {
+			line += " *"
+		}
+		if p.UnresolvedDouble {
+			line += " <!>"
+		}
+		if p.Deserted {
+			line += " (left)"
+		}
+
+		lines = append(lines, line)
+	}
+
+	for _, pl := range g.Players {
+		line := "hand " + pl.ID + ":"
+		for _, d := range pl.Hand {
+			line += " " + d.Display()
+		}
+		if pl.Knocked {
+			line += " knocked"
+		}
+
+		lines = append(lines, line)
+	}
+
+	if len(g.TilePool) > 0 {
+		line := "pool:"
+		for _, d := range g.TilePool {
+			line += " " + d.Display()
+		}
+
+		lines = append(lines, line)
+	}
+
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// ParseBoard reads a game in the format written by Game.Board.
+func ParseBoard(s string) (*Game, error) {
+	g := &Game{}
+
+	var (
+		centered bool
+		turn     string
+		turnLine int
+		hands    = map[string]bool{}
+		seen     = map[int]bool{}
+	)
+
+	// Every tile in a set is unique, so seeing one twice is a typo.
+	claim := func(n int, ds ...Domino) error {
+		for _, d := range ds {
+			i, _ := tileIndex(d)
+			if seen[i] {
+				return &BoardError{n, fmt.Sprintf("%s is on the board twice", d.Display())}
+			}
+			seen[i] = true
+		}
+		return nil
+	}
+
+	sc := bufio.NewScanner(strings.NewReader(s))
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		fields := strings.Fields(line)
+
+		switch {
+		case line == "" || strings.HasPrefix(line, "#"):
+			continue
+
+		case fields[0] == "center":
+			if len(fields) != 2 {
+				return nil, &BoardError{n, "center needs exactly one tile"}
+			}
+
+			d, err := parseTile(fields[1])
+			if err != nil {
+				return nil, &BoardError{n, err.Error()}
+			}
+			if !d.IsDouble() {
+				return nil, &BoardError{n, "the center tile must be a double"}
+			}
+
+			if err := claim(n, d); err != nil {
+				return nil, err
+			}
+
+			g.Center = d
+			centered = true
+
+		case fields[0] == "turn":
+			if len(fields) != 2 {
+				return nil, &BoardError{n, "turn needs exactly one player"}
+			}
+			turn, turnLine = fields[1], n
+
+		case fields[0] == "pool:":
+			tiles, err := parseTiles(fields[1:])
+			if err != nil {
+				return nil, &BoardError{n, err.Error()}
+			}
+			if err := claim(n, tiles...); err != nil {
+				return nil, err
+			}
+			g.TilePool = tiles
+
+		case fields[0] == "hand":
+			if len(fields) < 2 || !strings.HasSuffix(fields[1], ":") {
+				return nil, &BoardError{n, `hand lines look like "hand Xena: [4|2]"`}
+			}
+
+			id := strings.TrimSuffix(fields[1], ":")
+			pl, ok := g.GetPlayerByID(id)
+			if !ok {
+				return nil, &BoardError{n, fmt.Sprintf("%s has no path on the board", id)}
+			}
+			if hands[id] {
+				return nil, &BoardError{n, fmt.Sprintf("%s has two hands", id)}
+			}
+			hands[id] = true
+
+			rest := fields[2:]
+			if len(rest) > 0 && rest[len(rest)-1] == "knocked" {
+				pl.Knocked = true
+				rest = rest[:len(rest)-1]
+			}
+
+			tiles, err := parseTiles(rest)
+			if err != nil {
+				return nil, &BoardError{n, err.Error()}
+			}
+			if err := claim(n, tiles...); err != nil {
+				return nil, err
+			}
+			pl.Hand = tiles
+
+		case len(fields) >= 2 && fields[1] == ">>":
+			p, err := parsePath(fields)
+			if err != nil {
+				return nil, &BoardError{n, err.Error()}
+			}
+			for _, e := range p.Elements {
+				if err := claim(n, e.Domino); err != nil {
+					return nil, err
+				}
+			}
+
+			if p.MexicanTrain {
+				for _, other := range g.Trains {
+					if other.MexicanTrain {
+						return nil, &BoardError{n, "there is only one Mexican train"}
+					}
+				}
+			} else {
+				if _, ok := g.GetPlayerByID(p.Player); ok {
+					return nil, &BoardError{n, fmt.Sprintf("%s has two paths", p.Player)}
+				}
+
+				g.Players = append(g.Players, &Player{
+					ID:   p.Player,
+					Path: p,
+				})
+			}
+
+			if p.UnresolvedDouble {
+				g.UnresolvedDouble = true
+			}
+			g.Trains = append(g.Trains, p)
+
+		default:
+			return nil, &BoardError{n, fmt.Sprintf("don't know what to do with %q", line)}
+		}
+	}
+
+	if !centered {
+		return nil, &BoardError{0, "missing center tile"}
+	}
+
+	if turn != "" {
+		found := false
+		for i, pl := range g.Players {
+			if pl.ID == turn {
+				g.ActivePlayer = i
+				found = true
+			}
+		}
+
+		if !found {
+			return nil, &BoardError{turnLine, fmt.Sprintf("%s is not playing", turn)}
+		}
+	}
+
+	return g, nil
+}
+
+// parsePath reads the fields of a single path line.
+func parsePath(fields []string) (*Path, error) {
+	p := &Path{
+		Player: fields[0],
+	}
+	if p.Player == "M" {
+		p.Player = ""
+		p.MexicanTrain = true
+		p.Train = true
+	}
+
+	rest := fields[2:]
+	for len(rest) > 0 {
+		switch rest[len(rest)-1] {
+		case "*":
+			p.Train = true
+		case "<!>":
+			p.UnresolvedDouble = true
+		case "(left)":
+			p.Deserted = true
+		default:
+			tiles, err := parseTiles(rest)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, d := range tiles {
+				p.Elements = append(p.Elements, &Element{Domino: d})
+			}
+			return p, nil
+		}
+		rest = rest[:len(rest)-1]
+	}
+
+	return p, nil
+}
+
+func parseTiles(fields []string) ([]Domino, error) {
+	var result []Domino
+	for _, f := range fields {
+		d, err := parseTile(f)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, d)
+	}
+	return result, nil
+}
+
+// parseTile reads a tile as written by Domino.Display, optionally preceded by
+// an index as in Path.Display.
+func parseTile(s string) (Domino, error) {
+	if i := strings.Index(s, ":"); i >= 0 {
+		s = s[i+1:]
+	}
+
+	if !strings.HasPrefix(s, "[") || !strings.HasSuffix(s, "]") {
+		return Domino{}, fmt.Errorf("%q is not a tile", s)
+	}
+
+	sides := strings.SplitN(strings.Replace(s[1:len(s)-1], "||", "|", 1), "|", 2)
+	if len(sides) != 2 {
+		return Domino{}, fmt.Errorf("%q is not a tile", s)
+	}
+
+	left, err := strconv.Atoi(sides[0])
+	if err != nil || left < 0 {
+		return Domino{}, fmt.Errorf("%q is not a tile", s)
+	}
+
+	right, err := strconv.Atoi(sides[1])
+	if err != nil || right < 0 {
+		return Domino{}, fmt.Errorf("%q is not a tile", s)
+	}
+
+	return Domino{Left: left, Right: right}, nil
+}
@@ -0,0 +1,94 @@
+package dominos
+
+import (
+	"testing"
+)
+
+// vicDouble is the scenario "Vic has an open double on the Mexican train and
+// holds [4|2]".
+const vicDouble = `
+center [6||6]
+turn Vic
+    Xena >> [6|1] [1|4] *
+     Vic >> [6|3]
+       M >> [6|4] [4||4] <!>
+hand Xena: [5|5]
+hand Vic: [4|2] [3|0]
+pool: [2|0] [1|1]
+`
+
+func TestParseBoard(t *testing.T) {
+	g, err := ParseBoard(vicDouble)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if g.GetActivePlayer().ID != "Vic" {
+		t.Fatalf("wanted Vic to be up, got %s", g.GetActivePlayer().ID)
+	}
+
+	if !g.UnresolvedDouble || !g.Trains[2].MexicanTrain {
+		t.Fatalf("the open double on the Mexican train went missing:\n%s", g.Board())
+	}
+
+	vic := g.GetActivePlayer()
+	if _, err := g.CanPlace(vic, Domino{3, 0}, vic.Path); err != ErrDanglingDouble {
+		t.Fatalf("wanted %v, got %v", ErrDanglingDouble, err)
+	}
+
+	if _, err := g.CanPlace(vic, Domino{4, 2}, g.Trains[2]); err != nil {
+		t.Fatalf("[4|2] should satisfy the double: %v", err)
+	}
+
+	again, err := ParseBoard(g.Board())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.Board() != g.Board() {
+		t.Fatalf("board did not survive a round trip:\n%s\n%s", g.Board(), again.Board())
+	}
+}
+
+func TestParseBoardDisplay(t *testing.T) {
+	g, _ := NewGame([]string{"Xena", "Vic"})
+	g.Trains[0].Elements = []*Element{{Domino: Domino{g.Center.Left, 11}}}
+	g.Players[0].Hand = nil
+	g.Players[1].Hand = nil
+	g.TilePool = nil
+
+	board := "center " + g.Center.Display() + "\n"
+	for _, p := range g.Trains {
+		board += p.Display() + "\n"
+	}
+
+	g2, err := ParseBoard(board)
+	if err != nil {
+		t.Fatalf("could not parse Display output: %v\n%s", err, board)
+	}
+
+	if len(g2.Players) != 2 || len(g2.Trains[0].Elements) != 1 {
+		t.Fatalf("parsed the wrong board:\n%s", g2.Board())
+	}
+}
+
+func TestParseBadBoards(t *testing.T) {
+	cases := map[string]string{
+		"no center":         "Xena >> [6|1]",
+		"center not double": "center [6|1]",
+		"bad tile":          "center [6||6]\nXena >> [6|x]",
+		"duplicate tile":    "center [6||6]\nXena >> [6|1]\nVic >> [1|6]",
+		"unknown hand":      "center [6||6]\nhand Xena: [6|1]",
+		"unknown turn":      "center [6||6]\nXena >>\nturn Vic",
+		"gibberish":         "center [6||6]\nlol",
+	}
+
+	for name, board := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseBoard(board)
+			if err == nil {
+				t.Fatalf("expected an error for:\n%s", board)
+			}
+			t.Log(err)
+		})
+	}
+}
@@ -0,0 +1,287 @@
+package dominos
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"strings"
+)
+
+// codePrefix starts every position code so that a code from a future,
+// incompatible layout is rejected instead of misread.
+const codePrefix = "MT1-"
+
+// ErrBadCode is returned when a position code cannot be decoded.
+var ErrBadCode = errors.New("domino: invalid position code")
+
+// Flags packed into a single byte in position codes.
+const (
+	codeTrain = 1 << iota
+	codeUnresolvedDouble
+	codeMexicanTrain
+	codeBigPlay
+	codeKnocked
+	codeDeserted
+)
+
+// Code returns a compact, copy-pasteable encoding of the entire game
+// position, hidden information included. It is meant for bug reports and
+// test setups; ParseCode turns it back into a Game.
+//
+// The layout is a sequence of uvarints and flag bytes: the center tile, the
+// active player and game flags, then every path (owner, flags, elements),
+// every player (ID, flags, path index, hand) and finally the tile pool in
+// draw order. Each tile is its index in the set with its orientation packed
+// in the low bits.
+func (g *Game) Code() string {
+	var buf bytes.Buffer
+
+	putTile(&buf, g.Center, false)
+	putUvarint(&buf, uint64(g.ActivePlayer))
+	buf.WriteByte(flagIf(g.UnresolvedDouble, codeUnresolvedDouble))
+
+	putUvarint(&buf, uint64(len(g.Trains)))
+	for _, p := range g.Trains {
+		putString(&buf, p.Player)
+		buf.WriteByte(flagIf(p.Train, codeTrain) |
+			flagIf(p.UnresolvedDouble, codeUnresolvedDouble) |
+			flagIf(p.MexicanTrain, codeMexicanTrain) |
+			flagIf(p.Deserted, codeDeserted))
+		putUvarint(&buf, uint64(len(p.Elements)))
+		for _, e := range p.Elements {
+			putTile(&buf, e.Domino, e.Flipped)
+		}
+	}
+
+	putUvarint(&buf, uint64(len(g.Players)))
+	for _, pl := range g.Players {
+		putString(&buf, pl.ID)
+		buf.WriteByte(flagIf(pl.BigPlay, codeBigPlay) | flagIf(pl.Knocked, codeKnocked))
+
+		// Path indexes are stored off by one so that zero means "no path",
+		// which ParseCode turns down as every player has a path.
+		path := 0
+		for i, p := range g.Trains {
+			if p == pl.Path {
+				path = i + 1
+			}
+		}
+		putUvarint(&buf, uint64(path))
+
+		putTiles(&buf, pl.Hand)
+	}
+
+	putTiles(&buf, g.TilePool)
+
+	return codePrefix + base64.RawURLEncoding.EncodeToString(buf.Bytes())
+}
+
+// ParseCode decodes a position code created by Game.Code.
+func ParseCode(code string) (*Game, error) {
+	code = strings.TrimSpace(code)
+	if !strings.HasPrefix(code, codePrefix) {
+		return nil, ErrBadCode
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(code[len(codePrefix):])
+	if err != nil {
+		return nil, ErrBadCode
+	}
+
+	r := &codeReader{r: bytes.NewReader(data)}
+	g := &Game{}
+
+	g.Center, _ = r.tile()
+	g.ActivePlayer = r.int()
+	g.UnresolvedDouble = r.flags()&codeUnresolvedDouble != 0
+
+	for i := r.int(); i > 0 && r.err == nil; i-- {
+		p := &Path{
+			Player: r.string(),
+		}
+
+		flags := r.flags()
+		p.Train = flags&codeTrain != 0
+		p.UnresolvedDouble = flags&codeUnresolvedDouble != 0
+		p.MexicanTrain = flags&codeMexicanTrain != 0
+		p.Deserted = flags&codeDeserted != 0
+
+		for j := r.int(); j > 0 && r.err == nil; j-- {
+			d, flipped := r.tile()
+			p.Elements = append(p.Elements, &Element{Domino: d, Flipped: flipped})
+		}
+
+		g.Trains = append(g.Trains, p)
+	}
+
+	for i := r.int(); i > 0 && r.err == nil; i-- {
+		pl := &Player{
+			ID: r.string(),
+		}
+
+		flags := r.flags()
+		pl.BigPlay = flags&codeBigPlay != 0
+		pl.Knocked = flags&codeKnocked != 0
+
+		path := r.int()
+		if path == 0 || path > len(g.Trains) {
+			return nil, ErrBadCode
+		}
+		pl.Path = g.Trains[path-1]
+
+		pl.Hand = r.tiles()
+		g.Players = append(g.Players, pl)
+	}
+
+	g.TilePool = r.tiles()
+
+	if r.err != nil || r.r.Len() != 0 {
+		return nil, ErrBadCode
+	}
+
+	if g.ActivePlayer >= len(g.Players) {
+		return nil, ErrBadCode
+	}
+
+	return g, nil
+}
+
+// tileIndex numbers tiles the same way NewGame generates them: [0|0], [1|0],
+// [1|1], [2|0] and so on. swapped is true if d is stored low side first.
+func tileIndex(d Domino) (index int, swapped bool) {
+	hi, lo := d.Left, d.Right
+	if hi < lo {
+		hi, lo = lo, hi
+		swapped = true
+	}
+
+	return hi*(hi+1)/2 + lo, swapped
+}
+
+// tileAt is the inverse of tileIndex.
+func tileAt(index int, swapped bool) Domino {
+	hi := 0
+	for (hi+1)*(hi+2)/2 <= index {
+		hi++
+	}
+
+	d := Domino{Left: hi, Right: index - hi*(hi+1)/2}
+	if swapped {
+		d.Left, d.Right = d.Right, d.Left
+	}
+
+	return d
+}
+
+func putUvarint(buf *bytes.Buffer, v uint64) {
+	var scratch [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(scratch[:], v)
+	buf.Write(scratch[:n])
+}
+
+func putString(buf *bytes.Buffer, s string) {
+	putUvarint(buf, uint64(len(s)))
+	buf.WriteString(s)
+}
+
+func putTile(buf *bytes.Buffer, d Domino, flipped bool) {
+	index, swapped := tileIndex(d)
+	v := uint64(index) << 2
+	if swapped {
+		v |= 2
+	}
+	if flipped {
+		v |= 1
+	}
+	putUvarint(buf, v)
+}
+
+func putTiles(buf *bytes.Buffer, ds []Domino) {
+	putUvarint(buf, uint64(len(ds)))
+	for _, d := range ds {
+		putTile(buf, d, false)
+	}
+}
+
+func flagIf(set bool, flag byte) byte {
+	if set {
+		return flag
+	}
+	return 0
+}
+
+// codeReader reads the pieces of a position code, remembering the first
+// error so callers can check once at the end.
+type codeReader struct {
+	r   *bytes.Reader
+	err error
+}
+
+func (c *codeReader) uvarint() uint64 {
+	if c.err != nil {
+		return 0
+	}
+
+	v, err := binary.ReadUvarint(c.r)
+	if err != nil {
+		c.err = err
+	}
+	return v
+}
+
+// int reads a uvarint that is used as a count or index. No real game comes
+// anywhere near 1<<16 of anything, so larger values are garbage.
+func (c *codeReader) int() int {
+	v := c.uvarint()
+	if v > 1<<16 {
+		c.err = ErrBadCode
+		return 0
+	}
+	return int(v)
+}
+
+func (c *codeReader) flags() byte {
+	if c.err != nil {
+		return 0
+	}
+
+	b, err := c.r.ReadByte()
+	if err != nil {
+		c.err = err
+	}
+	return b
+}
+
+func (c *codeReader) string() string {
+	n := c.int()
+	if c.err != nil {
+		return ""
+	}
+
+	s := make([]byte, n)
+	_, err := io.ReadFull(c.r, s)
+	if err != nil {
+		c.err = err
+	}
+	return string(s)
+}
+
+func (c *codeReader) tile() (Domino, bool) {
+	v := c.uvarint()
+	if v>>2 > 1<<16 {
+		c.err = ErrBadCode
+		return Domino{}, false
+	}
+	return tileAt(int(v>>2), v&2 != 0), v&1 != 0
+}
+
+func (c *codeReader) tiles() []Domino {
+	var result []Domino
+	for i := c.int(); i > 0 && c.err == nil; i-- {
+		d, _ := c.tile()
+		result = append(result, d)
+	}
+	return result
+}
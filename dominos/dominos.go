@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"math/rand"
+	"strings"
 	"time"
 )
 
@@ -35,16 +36,16 @@ func (d Domino) Value() int {
 }
 
 // Emoji returns the emoji-fied version of the domino for Discord or slack.
+//
+// Deprecated: Discord only shows custom emoji written this way as text, use
+// a guild's Emojis instead.
 func (d Domino) Emoji() string {
-	return fmt.Sprintf("[:d%d:|:d%d:]", d.Left, d.Right)
+	return fmt.Sprintf("[:%s:|:%s:]", EmojiName(d.Left), EmojiName(d.Right))
 }
 
 // Display gives a human-readable version of this struct for debugging purposes.
 func (d Domino) Display() string {
-	if d.IsDouble() {
-		return fmt.Sprintf("[%d||%d]", d.Left, d.Right)
-	}
-	return fmt.Sprintf("[%d|%d]", d.Left, d.Right)
+	return Text{}.Tile(d)
 }
 
 // Game represents the total state for a single game
@@ -67,18 +68,17 @@ type Path struct {
 
 	UnresolvedDouble bool
 	MexicanTrain     bool
+	Deserted         bool // The owner left, so the train stays up for good.
 }
 
 // Display shows the player's hand for debugging purposes.
 func (p *Player) Display() string {
-	result := "YOUR HAND:"
-	for i, e := range p.Hand {
-		result = result + fmt.Sprintf(" %d:%s", i, e.Display())
-	}
-	return result
+	return strings.TrimSpace("YOUR HAND: " + RenderHand(Text{}, p.Hand))
 }
 
 // EmojiHand returns the player's hand emoji-formatted for Discord or Slack.
+//
+// Deprecated: use RenderHand with a guild's Emojis.
 func (p *Player) EmojiHand() string {
 	result := "Your hand: "
 	for i, e := range p.Hand {
@@ -89,24 +89,7 @@ func (p *Player) EmojiHand() string {
 
 // Display a path for debugging purposes.
 func (p *Path) Display() string {
-	result := ""
-
-	if p.MexicanTrain {
-		result = result + "       M >>"
-	} else {
-		result = result + fmt.Sprintf("%8s >>", p.Player)
-	}
-
-	for i, e := range p.Elements {
-		result = result + fmt.Sprintf(" %d:%s", i, e.Display())
-	}
-	if p.Train {
-		result = result + " *"
-	}
-	if p.UnresolvedDouble {
-		result = result + " <!>"
-	}
-	return result
+	return RenderPath(Text{}, p)
 }
 
 // Element is a wrapper for Domino that indicates if the Domino
@@ -159,21 +142,46 @@ func (e *Element) IsPlayable(prev *Element, next Domino) bool {
 
 // Display gives a human-readable version of this struct for debugging purposes.
 func (e *Element) Display() string {
-	if e.Flipped {
-		d := Domino{
-			Left:  e.Right,
-			Right: e.Left,
-		}
+	return RenderElement(Text{}, e)
+}
 
-		return d.Display()
-	}
+// MaxSet is the highest double of the biggest set a game is dealt from.
+const MaxSet = 18
 
-	return e.Domino.Display()
-}
+// Dealing errors
+var (
+	ErrNoPlayers   = errors.New("domino: a game needs at least one player")
+	ErrSetTooSmall = errors.New("domino: not enough tiles in the set for every player")
+)
 
 // NewGame creates a new game board out of a list of
 // players.
 func NewGame(players []string) (*Game, error) {
+	return NewGameSet(players, dominoCount(len(players)))
+}
+
+// NewGameSet creates a new game board out of a list of players, dealing from
+// a set whose highest double is set.
+func NewGameSet(players []string, set int) (*Game, error) {
+	return NewGameRand(players, set, nil)
+}
+
+// NewGameRand is NewGameSet with the tiles shuffled by r, so that the same
+// seed always deals the same game. A nil r uses the default source, and a
+// set of zero picks the set NewGame would.
+func NewGameRand(players []string, set int, r *rand.Rand) (*Game, error) {
+	if len(players) == 0 {
+		return nil, ErrNoPlayers
+	}
+
+	if set == 0 {
+		set = dominoCount(len(players))
+	}
+
+	if (set+1)*(set+2)/2 <= len(players)*HandCount(len(players)) {
+		return nil, ErrSetTooSmall
+	}
+
 	g := &Game{
 		Trains: make([]*Path, len(players)+1),
 	}
@@ -187,19 +195,23 @@ func NewGame(players []string) (*Game, error) {
 
 	// Generate the pool of tiles for the game
 	var doms []Domino
-	for i := 0; i <= dominoCount(len(players)); i++ {
+	for i := 0; i <= set; i++ {
 		for j := 0; j <= i; j++ {
 			doms = append(doms, Domino{i, j})
 		}
 	}
 
 	// Randomize the order of the tiles
-	for _, i := range rand.Perm(len(doms)) {
+	perm := rand.Perm
+	if r != nil {
+		perm = r.Perm
+	}
+	for _, i := range perm(len(doms)) {
 		g.TilePool = append(g.TilePool, doms[i])
 	}
 
 	// How many times should be pre-populated into a player's hand
-	hc := handCount(len(players))
+	hc := HandCount(len(players))
 
 	// Create player structures
 	for i, p := range players {
@@ -228,25 +240,74 @@ func NewGame(players []string) (*Game, error) {
 	var largest Domino
 	var starter int
 	var handIndex int
+	found := false
 
 	for i, player := range g.Players {
 		for j, dom := range player.Hand {
-			if dom.Left == dom.Right { // XXX maybe Domino.IsDouble :: Domino -> bool
-				if dom.Left > largest.Left {
-					largest = dom
-					starter = i
-					handIndex = j
-				}
+			if dom.IsDouble() && (!found || dom.Left > largest.Left) {
+				largest = dom
+				starter = i
+				handIndex = j
+				found = true
+			}
+		}
+	}
+
+	if found {
+		g.Center = largest
+		g.ActivePlayer = starter
+		g.GetActivePlayer().RemoveFromHand(handIndex)
+	} else {
+		// Nobody was dealt a double, so the highest one is still in the pool.
+		for i, dom := range g.TilePool {
+			if dom.IsDouble() && (!found || dom.Left > largest.Left) {
+				largest = dom
+				handIndex = i
+				found = true
 			}
 		}
+
+		g.Center = largest
+		g.TilePool = append(g.TilePool[:handIndex], g.TilePool[handIndex+1:]...)
 	}
-	g.Center = largest
-	g.ActivePlayer = starter
-	g.GetActivePlayer().RemoveFromHand(handIndex)
 
 	return g, nil
 }
 
+// Clone returns a deep copy of the game that shares nothing with it, so that
+// moves can be tried out on the copy.
+func (g *Game) Clone() *Game {
+	result := &Game{
+		TilePool:         append([]Domino(nil), g.TilePool...),
+		Trains:           make([]*Path, len(g.Trains)),
+		Center:           g.Center,
+		UnresolvedDouble: g.UnresolvedDouble,
+		ActivePlayer:     g.ActivePlayer,
+	}
+
+	paths := map[*Path]*Path{}
+	for i, path := range g.Trains {
+		cp := *path
+		cp.Elements = make([]*Element, len(path.Elements))
+		for j, e := range path.Elements {
+			ce := *e
+			cp.Elements[j] = &ce
+		}
+
+		result.Trains[i] = &cp
+		paths[path] = &cp
+	}
+
+	for _, p := range g.Players {
+		cp := *p
+		cp.Hand = append([]Domino(nil), p.Hand...)
+		cp.Path = paths[p.Path]
+		result.Players = append(result.Players, &cp)
+	}
+
+	return result
+}
+
 // Player is a single player in the game
 type Player struct {
 	Hand    []Domino
@@ -264,7 +325,7 @@ func removeHandAtIndex(hand []Domino, i int) []Domino {
 // RemoveFromHand when given index `at` will remove that element from the player's
 // hand, returning it for future use.
 func (p *Player) RemoveFromHand(at int) (Domino, bool) {
-	if len(p.Hand) >= at {
+	if at < 0 || at >= len(p.Hand) {
 		return Domino{}, false
 	}
 
@@ -296,83 +357,63 @@ var (
 // CanPlace returns an error if the given tile cannot be placed correctly and
 // returns the element of the target path if it is playable
 func (g *Game) CanPlace(pl *Player, d Domino, target *Path) (*Element, error) {
-	// Ownership checks. Players can play on the target path if they own it, it
-	// has a train on it or it is the mexican train.
-	if target.Player != pl.ID && !target.Train && !target.MexicanTrain {
-		return nil, ErrDontOwnPath
-	}
-
 	if g.UnresolvedDouble {
 		if !target.UnresolvedDouble {
 			return nil, ErrDanglingDouble
 		}
+	} else if target.Player != pl.ID && !target.Train && !target.MexicanTrain {
+		// Ownership checks. Players can play on the target path if they own it,
+		// it has a train on it or it is the mexican train. Anyone may satisfy
+		// a double, wherever it is.
+		return nil, ErrDontOwnPath
 	}
 
-	// If the target path is empty, compare simply against the center tile
-	// instead of checking for side matching.
-	if len(target.Elements) == 0 {
-		if g.Center.Left == d.Left || g.Center.Left == d.Right {
-			return &Element{Domino: d}, nil
-		}
-
-		if g.Center.IsPlayable(d) {
-			return &Element{Domino: d}, nil
-		}
-
-		return nil, ErrNotPlayable
+	end := target.End(g.Center)
+	switch end {
+	case d.Left:
+		return &Element{Domino: d}, nil
+	case d.Right:
+		return &Element{Domino: d, Flipped: true}, nil
 	}
 
-	var last *Element
-	if len(target.Elements) >= 2 {
-		last = target.Elements[len(target.Elements)-2]
-	} else {
-		last = &Element{Domino: g.Center}
-	}
-	curr := target.Elements[len(target.Elements)-1]
+	return nil, ErrNotPlayable
+}
 
-	if curr.IsPlayable(last, d) {
-		return &Element{Domino: d}, nil
+// End returns the pip value that the next tile placed on this path has to
+// match. An empty path starts from the center tile.
+func (p *Path) End(center Domino) int {
+	if len(p.Elements) == 0 {
+		return center.Right
 	}
 
-	return nil, ErrNotPlayable
+	// Elements are displayed left to right from the center outwards, so the
+	// free side is whatever ends up on the right.
+	last := p.Elements[len(p.Elements)-1]
+	if last.Flipped {
+		return last.Left
+	}
+	return last.Right
 }
 
 // Place sets given Domino d from Player pl to the Path target if it fits.
 func (g *Game) Place(pl *Player, d Domino, target *Path) error {
-	last, err := g.CanPlace(pl, d, target)
+	e, err := g.CanPlace(pl, d, target)
 	if err != nil {
 		return err
 	}
 
-	e := &Element{
-		Domino: d,
-	}
-
 	target.Elements = append(target.Elements, e)
 
 	// If the user has their train up and is playing on their own path, remove
 	// the train from the player.
-	if pl.ID == target.Player && pl.Path.Train {
+	if pl.ID == target.Player && pl.Path.Train && !target.Deserted {
 		pl.Path.Train = false
 	}
 
-	// Check if the domino needs to be flipped or not
-	if last.IsDouble() && d.Right == last.Right {
-		e.Flipped = true
-	}
-	if last.IsDouble() && d.Left == last.Left {
-		e.Flipped = true
-	}
-
-	if d.IsDouble() {
-		g.UnresolvedDouble = true
-		target.UnresolvedDouble = true
-	}
-
-	if last.IsDouble() && target.UnresolvedDouble && g.UnresolvedDouble {
-		g.UnresolvedDouble = false
-		target.UnresolvedDouble = false
-	}
+	// A double has to be satisfied before anything else is played, and any
+	// tile placed after it does so.
+	g.UnresolvedDouble = d.IsDouble()
+	target.UnresolvedDouble = d.IsDouble()
 
 	return nil
 }
@@ -418,7 +459,9 @@ func (g *Game) GetActivePlayer() *Player {
 	return g.Players[g.ActivePlayer]
 }
 
-func handCount(playernum int) int {
+// HandCount is how many tiles each player is dealt in a game of playernum
+// players.
+func HandCount(playernum int) int {
 	switch playernum {
 	case 2:
 		return 6
@@ -444,6 +487,6 @@ func dominoCount(playernum int) int {
 	case 9, 10, 11, 12:
 		return 15
 	default:
-		return 18
+		return MaxSet
 	}
 }
@@ -2,20 +2,22 @@ package dominos
 
 import (
 	"fmt"
+	"math/rand"
+	"reflect"
 	"testing"
 
 	"github.com/kr/pretty"
 )
 
 func TestNewGame(t *testing.T) {
-	g := NewGame([]string{"Xena"})
+	g, _ := NewGame([]string{"Xena"})
 	if g == nil {
 		t.Fatalf("game didn't initialize somehow :(")
 	}
 }
 
 func TestEndTurn(t *testing.T) {
-	g := NewGame([]string{"Xena", "Vic"})
+	g, _ := NewGame([]string{"Xena", "Vic"})
 	prev := g.GetActivePlayer()
 	p, _ := g.NextTurn()
 	if p == prev {
@@ -28,14 +30,14 @@ func TestEndTurn(t *testing.T) {
 }
 
 func TestRemoveFromHand(t *testing.T) {
-	g := NewGame([]string{"A", "B"})
+	g, _ := NewGame([]string{"A", "B"})
 	p := g.GetActivePlayer()
-	d := p.RemoveFromHand(0)
+	d, _ := p.RemoveFromHand(0)
 	t.Logf("Removed %s from %s's hand", d.Display(), p.ID)
 }
 
 func TestCantDraw(t *testing.T) {
-	g := NewGame([]string{"A", "B"})
+	g, _ := NewGame([]string{"A", "B"})
 	g.TilePool = nil
 	err := g.Draw(g.GetActivePlayer())
 	if err == nil {
@@ -44,7 +46,7 @@ func TestCantDraw(t *testing.T) {
 }
 
 func TestPlace(t *testing.T) {
-	g := NewGame([]string{"A", "B"})
+	g, _ := NewGame([]string{"A", "B"})
 	g.Trains = []*Path{
 		&Path{
 			Elements: []*Element{{
@@ -116,3 +118,96 @@ func TestIsPlayable(t *testing.T) {
 		})
 	}
 }
+
+func TestCode(t *testing.T) {
+	g, _ := NewGame([]string{"Xena", "Vic", "Gabrielle"})
+	g.Trains[1].Elements = []*Element{
+		{Domino: Domino{9, 2}},
+		{Domino: Domino{5, 2}, Flipped: true},
+	}
+	g.Trains[1].Train = true
+	g.Trains[3].UnresolvedDouble = true
+	g.Players[2].Knocked = true
+
+	code := g.Code()
+	t.Logf("%s", code)
+
+	g2, err := ParseCode(code)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if code2 := g2.Code(); code2 != code {
+		t.Fatalf("code did not survive a round trip:\n%s\n%s", code, code2)
+	}
+
+	if !reflect.DeepEqual(g.TilePool, g2.TilePool) {
+		t.Fatalf("tile pool order was lost")
+	}
+
+	for i, p := range g2.Players {
+		if p.Path != g2.Trains[i] {
+			t.Fatalf("player %s lost their path", p.ID)
+		}
+	}
+
+	// A player without a path.
+	g.Players[1].Path = nil
+	pathless := g.Code()
+
+	for _, bad := range []string{"", "MT1-", "MT1-!!!", code[:len(code)-3], code + "AA", pathless} {
+		_, err := ParseCode(bad)
+		if err == nil {
+			t.Fatalf("%q decoded without error", bad)
+		}
+	}
+}
+
+func TestClone(t *testing.T) {
+	g, _ := NewGame([]string{"Xena", "Vic"})
+	g.Trains[0].Elements = []*Element{{Domino: Domino{g.Center.Right, 3}}}
+
+	c := g.Clone()
+	if c.Code() != g.Code() {
+		t.Fatalf("clone differs:\n%s\n%s", g.Board(), c.Board())
+	}
+
+	for i, p := range c.Players {
+		if p.Path != c.Trains[i] {
+			t.Fatalf("player %s lost their path", p.ID)
+		}
+	}
+
+	c.Players[0].Hand[0] = Domino{}
+	c.Trains[0].Elements[0].Flipped = true
+	c.TilePool[0] = Domino{}
+	c.Trains[1].Train = true
+	if c.Code() == g.Code() {
+		t.Fatal("changing the clone changed the game")
+	}
+	if g.Trains[0].Elements[0].Flipped || g.Trains[1].Train {
+		t.Fatal("clone shares paths with the game")
+	}
+}
+
+func TestNewGameRand(t *testing.T) {
+	players := []string{"Xena", "Vic", "Gabrielle"}
+
+	g1, err := NewGameRand(players, 0, rand.New(rand.NewSource(42)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g2, err := NewGameRand(players, 0, rand.New(rand.NewSource(42)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if g1.Code() != g2.Code() {
+		t.Fatalf("the same seed dealt different games:\n%s\n%s", g1.Board(), g2.Board())
+	}
+
+	if highest := g1.Center.Left; highest > dominoCount(len(players)) {
+		t.Fatalf("dealt from the wrong set, center is %s", g1.Center.Display())
+	}
+}
@@ -0,0 +1,48 @@
+package dominos
+
+import "fmt"
+
+// Emojis are the custom emoji one Discord guild has for the faces of tiles,
+// by number of pips. Each is in the <:name:id> form, since Discord only
+// shows custom emoji by their ID and every guild's are different.
+type Emojis map[int]string
+
+// EmojiName is the name of the custom emoji for a face showing n pips.
+func EmojiName(n int) string {
+	return fmt.Sprintf("d%d", n)
+}
+
+// Tile implements Renderer. If either face of d has no emoji, it falls back
+// to Unicode, which draws the tiles beyond double-six as Text.
+func (e Emojis) Tile(d Domino) string {
+	left, lok := e[d.Left]
+	right, rok := e[d.Right]
+	if lok && rok {
+		return left + right
+	}
+
+	return Unicode{}.Tile(d)
+}
+
+// Missing returns the faces from zero up to max that have no emoji.
+func (e Emojis) Missing(max int) []int {
+	var missing []int
+	for n := 0; n <= max; n++ {
+		if _, ok := e[n]; !ok {
+			missing = append(missing, n)
+		}
+	}
+
+	return missing
+}
+
+// Unicode returns the Unicode domino tile showing d, laid flat. Unicode only
+// has tiles up to double-six, for anything bigger it returns false.
+func (d Domino) Unicode() (string, bool) {
+	if d.Left < 0 || d.Left > 6 || d.Right < 0 || d.Right > 6 {
+		return "", false
+	}
+
+	// DOMINO TILE HORIZONTAL-00-00 onwards, in order of left then right.
+	return string(rune(0x1F031 + 7*d.Left + d.Right)), true
+}
@@ -0,0 +1,44 @@
+package dominos
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEmojisTile(t *testing.T) {
+	e := Emojis{1: "<:d1:11>", 4: "<:d4:44>", 9: "<:d9:99>"}
+
+	cases := []struct {
+		d    Domino
+		want string
+	}{
+		{d: Domino{Left: 1, Right: 4}, want: "<:d1:11><:d4:44>"},
+		{d: Domino{Left: 9, Right: 9}, want: "<:d9:99><:d9:99>"},
+		{d: Domino{Left: 0, Right: 0}, want: "\U0001F031"},
+		{d: Domino{Left: 1, Right: 6}, want: "\U0001F03E"},
+		{d: Domino{Left: 6, Right: 6}, want: "\U0001F061"},
+		{d: Domino{Left: 9, Right: 3}, want: "[9|3]"},
+	}
+
+	for _, c := range cases {
+		if got := e.Tile(c.d); got != c.want {
+			t.Errorf("%s: got %q, want %q", c.d.Display(), got, c.want)
+		}
+	}
+
+	el := &Element{Domino: Domino{Left: 1, Right: 4}, Flipped: true}
+	if got := RenderElement(e, el); got != "<:d4:44><:d1:11>" {
+		t.Errorf("flipped: got %q", got)
+	}
+
+	if got := Emojis(nil).Tile(Domino{Left: 12, Right: 12}); got != "[12||12]" {
+		t.Errorf("no emoji: got %q", got)
+	}
+}
+
+func TestEmojisMissing(t *testing.T) {
+	e := Emojis{0: "<:d0:1>", 2: "<:d2:2>"}
+	if got, want := e.Missing(3), []int{1, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
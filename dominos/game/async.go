@@ -0,0 +1,89 @@
+package game
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Possible messages to the client in play-by-post games.
+const (
+	ReminderMsg   = "It's still your turn! Since you last played:\n%s"
+	LastCallMsg   = "Your turn will be skipped in %s"
+	NothingNewMsg = "nothing happened"
+)
+
+// Async configures play-by-post games, where a turn can take hours and the
+// active player is reminded privately until they get around to it. It takes
+// precedence over the Clock.
+type Async struct {
+	// Window is how long a turn is expected to take.
+	Window time.Duration
+
+	// Remind is how often the active player is reminded while it is their
+	// turn. Zero means they are never reminded.
+	Remind time.Duration
+
+	// Grace is how long after the window the turn is skipped.
+	Grace time.Duration
+}
+
+// Enabled returns true if games are played by post.
+func (a Async) Enabled() bool {
+	return a.Window > 0
+}
+
+// PlayByPost is a rule set for games that stretch over days, with a day for
+// every turn.
+var PlayByPost = RuleSet{
+	Name:       "play-by-post",
+	MinPlayers: 2,
+	MaxPlayers: 8,
+	Async: Async{
+		Window: 24 * time.Hour,
+		Remind: 8 * time.Hour,
+		Grace:  12 * time.Hour,
+	},
+	Hints: 3,
+}
+
+// reminders returns the reminder due for the active player of a
+// play-by-post game, if any, with a digest of what they missed.
+func (g *Game) reminders(left time.Duration) []*Response {
+	a := g.Rules.Async
+	if a.Remind <= 0 {
+		return nil
+	}
+
+	elapsed := clock().Sub(g.TurnStarted)
+	due := int(elapsed / a.Remind)
+	if due <= g.Warned {
+		return nil
+	}
+
+	// If several reminders came due while nobody was ticking the game, such
+	// as while the bot was down, only send one.
+	g.Warned = due
+
+	p := g.GetActivePlayer()
+
+	var lines []string
+	for _, e := range g.Digest(p.ID) {
+		lines = append(lines, "- "+e.Text)
+	}
+	if len(lines) == 0 {
+		lines = append(lines, NothingNewMsg)
+	}
+
+	msg := fmt.Sprintf(ReminderMsg, strings.Join(lines, "\n"))
+	if elapsed >= a.Window {
+		msg += "\n" + fmt.Sprintf(LastCallMsg, left.Truncate(time.Minute))
+	}
+
+	return []*Response{{
+		Success:     true,
+		State:       g.Game,
+		PlayerID:    p.ID,
+		UserMessage: msg,
+	}}
+}
@@ -0,0 +1,85 @@
+package game
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPlayByPost(t *testing.T) {
+	now, reset := fakeClock()
+	defer reset()
+
+	dir, err := ioutil.TempDir("", "magiism")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	fs, err := NewFileStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := fromBoard(t, `
+center [6||6]
+Xena >>
+Vic >>
+hand Xena: [6|1] [5|5]
+hand Vic: [5|2] [4|4]
+pool: [3|0] [0|0]
+`)
+	g.ID = "123456"
+	g.Rules = PlayByPost
+	g.startClock()
+
+	_, err = play(g, "Xena", 0, tile(6, 1))
+	if err != ErrEndOfTurn {
+		t.Fatalf("wanted %v, got %v", ErrEndOfTurn, err)
+	}
+
+	*now = now.Add(9 * time.Hour)
+	rs, _ := g.Tick()
+	if len(rs) != 1 || rs[0].PlayerID != "Vic" || !strings.Contains(rs[0].UserMessage, "Xena played [6|1]") {
+		t.Fatalf("Vic should have been reminded about Xena's play, got %#v", rs)
+	}
+
+	// The bot goes down for a while and is restarted.
+	err = fs.PutGame(g.ID, g)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ids, err := fs.Games()
+	if err != nil || len(ids) != 1 || ids[0] != g.ID {
+		t.Fatalf("wanted [%s], got %v, %v", g.ID, ids, err)
+	}
+
+	g, err = fs.GetGame(ids[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rs, _ = g.Tick()
+	if len(rs) != 0 {
+		t.Fatalf("Vic was already reminded, got %#v", rs)
+	}
+
+	*now = now.Add(23 * time.Hour)
+	rs, _ = g.Tick()
+	if len(rs) != 1 || !strings.Contains(rs[0].UserMessage, "skipped in 4h0m0s") {
+		t.Fatalf("Vic should have been told his turn is about to be skipped, got %#v", rs)
+	}
+
+	*now = now.Add(4 * time.Hour)
+	rs, _ = g.Tick()
+	if len(rs) != 1 || g.GetActivePlayer().ID != "Xena" || !g.Trains[1].Train {
+		t.Fatalf("Vic's turn should have been skipped:\n%s", g.Board())
+	}
+
+	if _, err := fs.GetGame("../../etc/passwd"); err != ErrGameNotFound {
+		t.Fatalf("wanted %v, got %v", ErrGameNotFound, err)
+	}
+}
@@ -0,0 +1,200 @@
+package game
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/cetacean/magiism/dominos"
+)
+
+// Possible messages to the client about the clock.
+const (
+	TimeWarningMsg = "You have %s left to take your turn"
+	TimedOutMsg    = "$EVENT_PLAYER_NAME ran out of time"
+)
+
+// clock is where the game gets the current time from. Tests replace it.
+var clock = time.Now
+
+// Clock configures how long players have to take their turns.
+type Clock struct {
+	// Turn limits how long each turn may take. Zero means turns are untimed.
+	Turn time.Duration
+
+	// Bank gives every player this much time for the whole game instead,
+	// like a chess clock. Once a player's bank is empty, every one of their
+	// turns times out straight away.
+	Bank time.Duration
+
+	// Warnings lists how long before running out of time a player is told
+	// to hurry up.
+	Warnings []time.Duration
+
+	// AutoPlay plays a player's tile for them when time runs out, as long as
+	// there is only one tile they could play.
+	AutoPlay bool
+}
+
+// Timed returns true if the clock limits turns at all.
+func (c Clock) Timed() bool {
+	return c.Turn > 0 || c.Bank > 0
+}
+
+// Deadline returns when the active player runs out of time, or false if
+// turns are untimed.
+func (g *Game) Deadline() (time.Time, bool) {
+	c := g.Rules.Clock
+	a := g.Rules.Async
+
+	switch {
+	case a.Enabled():
+		return g.TurnStarted.Add(a.Window + a.Grace), true
+	case c.Bank > 0:
+		return g.TurnStarted.Add(g.bankOf(g.GetActivePlayer().ID)), true
+	case c.Turn > 0:
+		return g.TurnStarted.Add(c.Turn), true
+	}
+
+	return time.Time{}, false
+}
+
+// bankOf returns how much time the given player has left in their bank.
+func (g *Game) bankOf(id string) time.Duration {
+	left, ok := g.Banks[id]
+	if !ok {
+		return g.Rules.Clock.Bank
+	}
+
+	return left
+}
+
+// startClock starts timing the active player's turn.
+func (g *Game) startClock() {
+	g.TurnStarted = clock()
+	g.Warned = 0
+}
+
+// stopClock charges the time the active player took to their bank.
+func (g *Game) stopClock() {
+	if g.Rules.Clock.Bank <= 0 || g.TurnStarted.IsZero() {
+		return
+	}
+
+	id := g.GetActivePlayer().ID
+	left := g.bankOf(id) - clock().Sub(g.TurnStarted)
+	if left < 0 {
+		left = 0
+	}
+
+	if g.Banks == nil {
+		g.Banks = map[string]time.Duration{}
+	}
+	g.Banks[id] = left
+}
+
+// Tick checks the clock. Frontends should call it every few seconds while a
+// game is timed. It returns the warnings or play-by-post reminders to send to
+// the active player and, if they ran out of time, the result of the moves
+// made on their behalf: they draw if they haven't yet, play their only
+// playable tile if the rules say so, and otherwise put their train up and
+// pass.
+func (g *Game) Tick() ([]*Response, error) {
+	if g.phaseError() != nil {
+		return nil, nil
+	}
+
+	deadline, ok := g.Deadline()
+	if !ok {
+		return nil, nil
+	}
+
+	// Games from before the clock was turned on start timing now.
+	if g.TurnStarted.IsZero() {
+		g.startClock()
+		return nil, nil
+	}
+
+	p := g.GetActivePlayer()
+	left := deadline.Sub(clock())
+
+	if left > 0 && g.Rules.Async.Enabled() {
+		return g.reminders(left), nil
+	}
+
+	if left > 0 {
+		warnings := append([]time.Duration(nil), g.Rules.Clock.Warnings...)
+		sort.Sort(sort.Reverse(durations(warnings)))
+
+		var result []*Response
+		for ; g.Warned < len(warnings) && left <= warnings[g.Warned]; g.Warned++ {
+			result = append(result, &Response{
+				Success:     true,
+				State:       g.Game,
+				PlayerID:    p.ID,
+				UserMessage: fmt.Sprintf(TimeWarningMsg, left.Truncate(time.Second)),
+			})
+		}
+
+		return result, nil
+	}
+
+	r := &Response{
+		Success:       true,
+		State:         g.Game,
+		PlayerID:      p.ID,
+		GlobalMessage: TimedOutMsg,
+	}
+	g.logf(p.ID, EndTurn, "%s ran out of time", p.ID)
+
+	if !g.Drawn && len(g.TilePool) > 0 {
+		g.Draw(p)
+		g.Drawn = true
+	}
+
+	// Playing a double keeps the turn going, so keep at it while there is
+	// exactly one thing to play.
+	for g.Rules.Clock.AutoPlay && g.GetActivePlayer() == p {
+		plays := g.plays(p)
+		if len(plays) != 1 {
+			break
+		}
+
+		pr, err := g.HandleEvent(plays[0])
+		if err != nil && err != ErrEndOfTurn {
+			return nil, err
+		}
+		r.GlobalMessage += "\n" + pr.GlobalMessage
+		if err == ErrEndOfTurn {
+			return []*Response{r}, nil
+		}
+	}
+
+	g.pass(r)
+	return []*Response{r}, nil
+}
+
+// plays lists every tile the given player could play right now.
+func (g *Game) plays(p *dominos.Player) []*Event {
+	var result []*Event
+	for i, d := range p.Hand {
+		for j, path := range g.Trains {
+			if g.canPlay(p, d, path) == nil {
+				result = append(result, &Event{
+					Action:    PlayDomino,
+					PlayerID:  p.ID,
+					PathID:    j,
+					HandIndex: i,
+				})
+			}
+		}
+	}
+
+	return result
+}
+
+type durations []time.Duration
+
+func (d durations) Len() int           { return len(d) }
+func (d durations) Less(i, j int) bool { return d[i] < d[j] }
+func (d durations) Swap(i, j int)      { d[i], d[j] = d[j], d[i] }
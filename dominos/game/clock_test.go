@@ -0,0 +1,101 @@
+package game
+
+import (
+	"testing"
+	"time"
+)
+
+// fakeClock replaces the game clock with one that only moves when told to.
+// Call the returned function to put the real clock back.
+func fakeClock() (*time.Time, func()) {
+	now := time.Date(2017, 3, 1, 12, 0, 0, 0, time.UTC)
+	clock = func() time.Time { return now }
+	return &now, func() { clock = time.Now }
+}
+
+func TestTurnTimeout(t *testing.T) {
+	now, reset := fakeClock()
+	defer reset()
+
+	g := fromBoard(t, `
+center [6||6]
+Xena >> [6|1]
+Vic >> [6|3]
+hand Xena: [5|5] [2|2]
+hand Vic: [5|0] [4|4]
+pool: [1|4] [0|0]
+`)
+	g.Rules.Clock = Clock{
+		Turn:     time.Minute,
+		Warnings: []time.Duration{10 * time.Second, 30 * time.Second},
+		AutoPlay: true,
+	}
+	g.startClock()
+
+	*now = now.Add(40 * time.Second)
+	rs, err := g.Tick()
+	if err != nil || len(rs) != 1 || rs[0].PlayerID != "Xena" {
+		t.Fatalf("wanted one warning for Xena, got %v, %v", rs, err)
+	}
+
+	*now = now.Add(15 * time.Second)
+	rs, _ = g.Tick()
+	if len(rs) != 1 {
+		t.Fatalf("wanted the second warning, got %v", rs)
+	}
+
+	rs, _ = g.Tick()
+	if len(rs) != 0 {
+		t.Fatalf("warnings should only be sent once, got %v", rs)
+	}
+
+	// Xena draws [1|4] and, as that is the only thing she can play, plays it.
+	*now = now.Add(5 * time.Second)
+	rs, err = g.Tick()
+	if err != nil || len(rs) != 1 {
+		t.Fatalf("wanted a timeout, got %v, %v", rs, err)
+	}
+
+	if g.GetActivePlayer().ID != "Vic" || len(g.Trains[0].Elements) != 2 || g.Trains[0].Train {
+		t.Fatalf("Xena's timeout should have played [1|4]:\n%s", g.Board())
+	}
+
+	// Vic draws [0|0] and can't play, so his train goes up.
+	*now = now.Add(time.Minute)
+	g.Tick()
+
+	if g.GetActivePlayer().ID != "Xena" || !g.Trains[1].Train || len(g.Players[1].Hand) != 3 {
+		t.Fatalf("Vic's timeout should have drawn and passed:\n%s", g.Board())
+	}
+}
+
+func TestTimeBank(t *testing.T) {
+	now, reset := fakeClock()
+	defer reset()
+
+	g := fromBoard(t, `
+center [6||6]
+Xena >>
+Vic >>
+hand Xena: [6|1] [5|5]
+hand Vic: [6|2] [4|4]
+pool: [1|4] [0|0]
+`)
+	g.Rules.Clock = Clock{Bank: time.Minute}
+	g.startClock()
+
+	*now = now.Add(45 * time.Second)
+	_, err := play(g, "Xena", 0, tile(6, 1))
+	if err != ErrEndOfTurn {
+		t.Fatalf("wanted %v, got %v", ErrEndOfTurn, err)
+	}
+
+	if g.Banks["Xena"] != 15*time.Second {
+		t.Fatalf("Xena should have 15s left, has %s", g.Banks["Xena"])
+	}
+
+	deadline, ok := g.Deadline()
+	if !ok || deadline.Sub(*now) != time.Minute {
+		t.Fatalf("Vic should still have his full bank, deadline is %s", deadline)
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"math/rand"
+	"time"
 
 	"github.com/Xe/uuid"
 	"github.com/cetacean/magiism/dominos"
@@ -15,6 +17,7 @@ var (
 	ErrGameCreationFailed = errors.New("game: dominos.NewGame failed, please report as a bug")
 	ErrNotYourTurn        = errors.New("game: it is not your turn")
 	ErrInvalidHandIndex   = errors.New("game: invalid hand index")
+	ErrInvalidPathIndex   = errors.New("game: invalid path index")
 	ErrEndOfTurn          = errors.New("game: your turn is now over")
 	ErrUnknownAction      = errors.New("game: unknown action")
 )
@@ -28,6 +31,8 @@ const (
 	PlayDomino
 	DrawDomino
 	Knock
+	Forfeit
+	Hint
 )
 
 // Possible messages to the client, TODO: translations?
@@ -39,6 +44,8 @@ const (
 	PlaySuccessfulMsg    = "$EVENT_PLAYER_NAME has played $DOMINO on $PATH_ID_OWNER"
 	MustTryDrawingMsg    = "You must try to draw a tile and see if that works before ending your turn"
 	SettingTrainMsg      = "Setting train on $EVENT_PLAYER_NAME"
+	DominoMsg            = "$EVENT_PLAYER_NAME has played their last tile, the round is over"
+	BlockedMsg           = "Nobody can play and the boneyard is empty, the round is over"
 )
 
 // Event is a single user command -> game state event.
@@ -49,6 +56,9 @@ type Event struct {
 	// If PlayDomino is chosen, these next two fields are filled.
 	PathID    int
 	HandIndex int
+
+	// If Forfeit is chosen, this says what happens to the player's seat.
+	Departure Departure
 }
 
 // Response is the result of the event being run against the game state.
@@ -67,11 +77,45 @@ type Response struct {
 type Game struct {
 	*dominos.Game
 
-	ID string
+	ID    string
+	Phase Phase
+	Rules RuleSet
+
+	// Round counts the rounds of the match from one up to Rounds.
+	Round  int
+	Rounds int
+
+	// Scores holds the pips left in each player's hand, summed over every
+	// finished round. Lowest score wins the match.
+	Scores map[string]int
 
 	// These variables are for the currently active player's turn.
 	Drawn  bool
 	Played bool
+
+	// Passes counts the turns in a row that ended without a tile being played.
+	Passes int
+
+	// HintsUsed counts the hints each player has taken this round.
+	HintsUsed map[string]int
+
+	// Log records everything that happened in the game.
+	Log []Entry
+
+	// Seats holds who plays for players that are no longer playing
+	// themselves. Anyone missing is Human.
+	Seats map[string]Seat
+
+	// TurnStarted is when the active player's turn began and Warned how many
+	// of the clock's warnings they have been sent since. Banks holds the
+	// time every player has left when playing with a time bank.
+	TurnStarted time.Time
+	Warned      int
+	Banks       map[string]time.Duration
+
+	// Rand shuffles the tiles, if set, so that a seeded game plays out the
+	// same way every time. It isn't saved.
+	Rand *rand.Rand
 }
 
 // Store represents a in-memory or on-database storage for many domino games.
@@ -82,22 +126,123 @@ type Store interface {
 
 // New creates a new game with given players.
 func New(players []string) (*Game, error) {
-	dg, err := dominos.NewGame(players)
+	return NewWithRules(players, Standard)
+}
+
+// NewWithRules creates a new game with given players and rules.
+func NewWithRules(players []string, rules RuleSet) (*Game, error) {
+	return NewWithRand(players, rules, nil)
+}
+
+// NewWithRand creates a new game with given players and rules that shuffles
+// the tiles with r. A nil r uses the default source.
+func NewWithRand(players []string, rules RuleSet, r *rand.Rand) (*Game, error) {
+	err := rules.CheckPlayers(len(players))
 	if err != nil {
-		log.Println("game creation failed: ", err)
-		return nil, ErrGameCreationFailed
+		return nil, err
+	}
+
+	err = rules.CheckSet()
+	if err != nil {
+		return nil, err
+	}
+
+	dg, err := deal(players, rules, r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Every round is dealt from the same set, even once there are fewer
+	// players left to pick one for.
+	if rules.Set == 0 {
+		rules.Set = HighestDouble(dg)
 	}
 
 	g := &Game{
-		Game: dg,
-		ID:   uuid.New(),
+		Game:  dg,
+		ID:    uuid.New(),
+		Rules: rules,
+		Rand:  r,
+
+		Round:  1,
+		Rounds: rules.Set + 1,
+		Scores: map[string]int{},
 	}
 
+	err = g.setPhase(BigTurn)
+	if err != nil {
+		return nil, err
+	}
+	g.startClock()
+
 	return g, nil
 }
 
+// deal creates the board for a single round.
+func deal(players []string, rules RuleSet, r *rand.Rand) (*dominos.Game, error) {
+	dg, err := dominos.NewGameRand(players, rules.Set, r)
+
+	switch err {
+	case nil, dominos.ErrSetTooSmall:
+		return dg, err
+	default:
+		log.Println("game creation failed: ", err)
+		return nil, ErrGameCreationFailed
+	}
+}
+
+// NextRound deals the next round of the match once the current one is over,
+// or finishes the match if that was the last round.
+func (g *Game) NextRound() error {
+	switch g.Phase {
+	case BigTurn, Playing:
+		return ErrRoundNotOver
+	case RoundOver:
+	default:
+		return g.phaseError()
+	}
+
+	if g.Round >= g.Rounds {
+		return g.setPhase(MatchOver)
+	}
+
+	var players []string
+	for _, p := range g.Players {
+		if g.SeatOf(p.ID) != Gone {
+			players = append(players, p.ID)
+		}
+	}
+
+	dg, err := deal(players, g.Rules, g.Rand)
+	if err != nil {
+		return err
+	}
+
+	err = g.setPhase(BigTurn)
+	if err != nil {
+		return err
+	}
+
+	g.Game = dg
+	g.Round++
+	g.Drawn = false
+	g.Played = false
+	g.Passes = 0
+	g.HintsUsed = nil
+	g.reseat()
+	g.startClock()
+	g.logf("", EndTurn, "Round %d of %d started with %s in the center", g.Round, g.Rounds, g.Center.Display())
+
+	return nil
+}
+
 // HandleEvent handles a single game event, failing if it failed.
 func (g *Game) HandleEvent(e *Event) (*Response, error) {
+	err := g.phaseError()
+	if err != nil {
+		return nil, err
+	}
+
 	r := &Response{
 		State:    g.Game,
 		PlayerID: e.PlayerID,
@@ -105,6 +250,18 @@ func (g *Game) HandleEvent(e *Event) (*Response, error) {
 
 	p := g.GetActivePlayer()
 
+	if e.Action == Forfeit {
+		return g.Leave(e.PlayerID, e.Departure)
+	}
+
+	if e.Action == Hint {
+		if p.ID != e.PlayerID {
+			return nil, ErrNotYourTurn
+		}
+
+		return g.hint(p, r)
+	}
+
 	if e.Action == Knock {
 		if e.PlayerID != p.ID {
 			kp, ok := g.GetPlayerByID(e.PlayerID)
@@ -114,6 +271,7 @@ func (g *Game) HandleEvent(e *Event) (*Response, error) {
 
 			if g.Knock(kp) {
 				r.GlobalMessage = KnockSuccessfulMsg
+				g.logf(kp.ID, Knock, "%s knocked", kp.ID)
 				return r, nil
 			}
 			return nil, ErrNotYourTurn
@@ -127,59 +285,76 @@ func (g *Game) HandleEvent(e *Event) (*Response, error) {
 	// switch on e.Action and then take the appropriate actions.
 	switch e.Action {
 	case EndTurn:
-		nagged := false
-		for i, d := range p.Hand {
-			for j, path := range g.Trains {
-				_, err := g.CanPlace(p, d, path)
-				if err == nil {
-					nagged = true
-					r.UserMessage += fmt.Sprintf("you can place tile %s (%d) in your hand on path %d\n", d.Display(), i, j)
-				}
+		// Once something has been played in a big turn, the player may stop
+		// whenever they like, unless it was a double they still have to
+		// cover.
+		if !g.bigTurnDone() {
+			plays := g.plays(p)
+			for _, pe := range plays {
+				r.UserMessage += fmt.Sprintf("you can place tile %s (%d) in your hand on path %d\n", p.Hand[pe.HandIndex].Display(), pe.HandIndex, pe.PathID)
 			}
-		}
 
-		if nagged {
-			r.Success = false
-			return r, nil
+			if len(plays) > 0 {
+				r.Success = false
+				return r, nil
+			}
 		}
 
-		if !g.Played && !g.Drawn {
+		if g.mustDraw() {
 			r.UserMessage = MustTryDrawingMsg
 			r.Success = false
 			return r, nil
 		}
 
-		if g.Drawn && !g.Played {
-			r.GlobalMessage += "\n" + SettingTrainMsg
-		}
-
-		g.endOfTurn(r)
+		g.pass(r)
 		return r, ErrEndOfTurn
 
 	case PlayDomino:
+		if e.PathID < 0 || e.PathID >= len(g.Trains) {
+			return nil, ErrInvalidPathIndex
+		}
 		path := g.Trains[e.PathID]
-		d, ok := p.RemoveFromHand(e.HandIndex)
-		if !ok {
+
+		if e.HandIndex < 0 || e.HandIndex >= len(p.Hand) {
 			return nil, ErrInvalidHandIndex
 		}
+		d := p.Hand[e.HandIndex]
+
+		err := g.canPlay(p, d, path)
+		if err != nil {
+			return nil, err
+		}
 
-		err := g.Place(p, d, path)
+		p.RemoveFromHand(e.HandIndex)
+		err = g.Place(p, d, path)
 		if err != nil {
 			p.Hand = append(p.Hand, d)
 
 			return nil, err
 		}
 
+		g.logf(p.ID, PlayDomino, "%s played %s on %s", p.ID, d.Display(), pathName(path))
 		r.GlobalMessage = PlaySuccessfulMsg
 		r.Success = true
 		g.Played = true
+		g.Passes = 0
+
+		if len(p.Hand) == 0 {
+			g.endOfTurn(r)
+			return r, ErrEndOfTurn
+		}
 
 		if d.IsDouble() {
-			g.UnresolvedDouble = true
-			path.UnresolvedDouble = true
 			r.UserMessage = MustResolveDoubleMsg
-			g.Played = false
+			if g.Phase == Playing {
+				g.Played = false
+			}
+
+			return r, nil
+		}
 
+		// A big turn goes on until the player ends it.
+		if g.Phase == BigTurn {
 			return r, nil
 		}
 
@@ -187,23 +362,25 @@ func (g *Game) HandleEvent(e *Event) (*Response, error) {
 		return r, ErrEndOfTurn
 
 	case DrawDomino:
-		if !g.Drawn {
-			g.Drawn = true
-			err := g.Draw(p)
-			if err != nil {
-				r.GlobalMessage = OutOfTilesMsg
-				g.endOfTurn(r)
-				return r, ErrEndOfTurn
-			}
-		} else {
-			r.Success = false
+		if g.Drawn {
+			return nil, ErrAlreadyDrawn
 		}
 
+		err := g.Draw(p)
+		if err != nil {
+			return nil, ErrBoneyardEmpty
+		}
+
+		g.Drawn = true
+		r.Success = true
+		g.logf(p.ID, DrawDomino, "%s drew a tile", p.ID)
+
 	case Knock:
 		if g.Knock(p) {
 			r.GlobalMessage = KnockSuccessfulMsg
 			r.Success = true
 			p.Knocked = true
+			g.logf(p.ID, Knock, "%s knocked", p.ID)
 			return r, nil
 		} else {
 			r.UserMessage = CannotKnockMsg
@@ -216,15 +393,156 @@ func (g *Game) HandleEvent(e *Event) (*Response, error) {
 	return r, nil
 }
 
+// canPlay is CanPlace plus the rules that depend on the phase of the game.
+// A double left open in a big turn may be covered by anyone, or the big
+// turns after it could never be played.
+func (g *Game) canPlay(p *dominos.Player, d dominos.Domino, path *dominos.Path) error {
+	if g.Phase == BigTurn && path != p.Path && !path.UnresolvedDouble {
+		return ErrBigTurnOwnPath
+	}
+
+	_, err := g.CanPlace(p, d, path)
+	return err
+}
+
+// bigTurnDone returns true if the active player has played in their big
+// turn and may end it, which they can't with a double left open.
+func (g *Game) bigTurnDone() bool {
+	return g.Phase == BigTurn && g.Played && !g.UnresolvedDouble
+}
+
+// mustDraw returns true if the active player has to draw before ending
+// their turn, because they haven't played yet or left a double open.
+func (g *Game) mustDraw() bool {
+	return (!g.Played || g.UnresolvedDouble) && !g.Drawn && len(g.TilePool) > 0
+}
+
+// pass ends the active player's turn, putting their train up if they didn't
+// play anything.
+func (g *Game) pass(r *Response) {
+	p := g.GetActivePlayer()
+	if !g.Played {
+		p.Path.Train = true
+		r.GlobalMessage += "\n" + SettingTrainMsg
+		g.logf(p.ID, EndTurn, "%s passed and put their train up", p.ID)
+	} else {
+		g.logf(p.ID, EndTurn, "%s ended their turn", p.ID)
+	}
+
+	g.endOfTurn(r)
+}
+
 func (g *Game) endOfTurn(r *Response) {
+	p := g.GetActivePlayer()
+	if !g.Played {
+		g.Passes++
+	}
+
 	g.Drawn = false
 	g.Played = false
 
-	_, status := g.NextTurn()
-	if status != "" {
-		switch status {
-		case "noknock":
-			r.GlobalMessage += fmt.Sprintf("\n$CURRENT_PLAYER has drawn two tiles for not knocking when they had one tile left")
+	if len(p.Hand) == 0 && g.SeatOf(p.ID) != Gone {
+		r.GlobalMessage += "\n" + DominoMsg
+		g.logf("", EndTurn, "%s played their last tile, round %d is over", p.ID, g.Round)
+		g.endRound()
+		return
+	}
+
+	if len(g.TilePool) == 0 && g.Passes >= g.seated() {
+		r.GlobalMessage += "\n" + BlockedMsg
+		g.logf("", EndTurn, "Nobody can play, round %d is over", g.Round)
+		g.endRound()
+		return
+	}
+
+	if g.Phase == BigTurn {
+		p.BigPlay = true
+	}
+
+	g.advance(r)
+}
+
+// advance hands the turn to the next player that is still taking turns.
+func (g *Game) advance(r *Response) {
+	g.stopClock()
+	defer g.startClock()
+
+	n := len(g.Players)
+	for i := 1; i <= n; i++ {
+		idx := (g.ActivePlayer + i) % n
+		if g.skipped(g.Players[idx].ID) {
+			continue
 		}
+
+		// Hop over the skipped seats so that NextTurn lands on idx.
+		g.ActivePlayer = (idx + n - 1) % n
+
+		next, status := g.NextTurn()
+		if status != "" {
+			switch status {
+			case "noknock":
+				r.GlobalMessage += fmt.Sprintf("\n$CURRENT_PLAYER has drawn two tiles for not knocking when they had one tile left")
+				g.logf("", Knock, "%s drew two tiles for not knocking", next.ID)
+			}
+		}
+
+		// The big turn is over once it comes back around to someone who has
+		// had theirs.
+		if g.Phase == BigTurn && next.BigPlay {
+			g.setPhase(Playing)
+		}
+
+		return
 	}
+
+	// Nobody is left to take a turn.
+	g.setPhase(Abandoned)
+}
+
+// endRound scores what is left in everyone's hand and finishes the round.
+func (g *Game) endRound() {
+	if g.Scores == nil {
+		g.Scores = map[string]int{}
+	}
+
+	// Going out scores nothing, which still puts the player in the
+	// standings.
+	for _, p := range g.Players {
+		score := g.Scores[p.ID]
+		for _, d := range p.Hand {
+			score += d.Value()
+		}
+		g.Scores[p.ID] = score
+	}
+
+	g.setPhase(RoundOver)
+}
+
+// HighestDouble returns the highest pip value in the set the game was dealt
+// from. It looks at every tile, hidden or not, so it is only for working out
+// the set of a game that hasn't recorded it; everyone can see Rounds.
+func HighestDouble(dg *dominos.Game) int {
+	highest := dg.Center.Left
+	check := func(ds []dominos.Domino) {
+		for _, d := range ds {
+			if d.Left > highest {
+				highest = d.Left
+			}
+			if d.Right > highest {
+				highest = d.Right
+			}
+		}
+	}
+
+	check(dg.TilePool)
+	for _, p := range dg.Players {
+		check(p.Hand)
+	}
+	for _, path := range dg.Trains {
+		for _, e := range path.Elements {
+			check([]dominos.Domino{e.Domino})
+		}
+	}
+
+	return highest
 }
@@ -0,0 +1,166 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/cetacean/magiism/dominos"
+)
+
+// Hint errors
+var (
+	ErrHintsDisabled = errors.New("game: hints are turned off for this game")
+	ErrNoHintsLeft   = errors.New("game: you have used up your hints for this round")
+)
+
+// Possible messages to the client about hints.
+const (
+	HintMsg       = "Try %s: %s"
+	HintsLeftMsg  = "(%d hints left this round)"
+	HintTakenMsg  = "$EVENT_PLAYER_NAME took a hint"
+	HintKnock     = "you only have one tile left"
+	HintDouble    = "it satisfies the open double on %s"
+	HintTakeDown  = "it takes your train down and extends your longest chain"
+	HintOffChain  = "it gets rid of %d pips without breaking your longest chain"
+	HintChain     = "it extends your longest chain"
+	HintHeaviest  = "it gets rid of the most pips"
+	HintDraw      = "you have nothing to play"
+	HintNothing   = "nothing fits and you have already drawn"
+	HintFinishBig = "you have played what you can on your own train"
+)
+
+// hint answers a Hint event: it tells the active player what they could do
+// and why, and records that they asked so that everyone can see it.
+func (g *Game) hint(p *dominos.Player, r *Response) (*Response, error) {
+	if g.Rules.Hints <= 0 {
+		return nil, ErrHintsDisabled
+	}
+
+	if g.HintsUsed[p.ID] >= g.Rules.Hints {
+		return nil, ErrNoHintsLeft
+	}
+
+	// There is always something to do on your turn.
+	e, reason, ok := g.suggest(p)
+	if !ok {
+		return nil, ErrNotYourTurn
+	}
+
+	if g.HintsUsed == nil {
+		g.HintsUsed = map[string]int{}
+	}
+	g.HintsUsed[p.ID]++
+
+	g.logf(p.ID, Hint, "%s took a hint", p.ID)
+
+	r.Success = true
+	r.GlobalMessage = HintTakenMsg
+	r.UserMessage = fmt.Sprintf(HintMsg, g.describe(p, e), reason) + "\n" +
+		fmt.Sprintf(HintsLeftMsg, g.Rules.Hints-g.HintsUsed[p.ID])
+
+	return r, nil
+}
+
+// suggest picks a good move for the given player along with the reason for
+// it. It plans the player's own train as the longest chain they can make,
+// takes their train down with it if it is up and otherwise sends the
+// heaviest tile that isn't part of it elsewhere.
+func (g *Game) suggest(p *dominos.Player) (Event, string, bool) {
+	moves := g.LegalMoves(p.ID)
+	if len(moves) == 0 {
+		return Event{}, "", false
+	}
+
+	var (
+		plays      []Event
+		draw, stop *Event
+	)
+	for i, e := range moves {
+		switch e.Action {
+		case Knock:
+			return e, HintKnock, true
+		case PlayDomino:
+			plays = append(plays, e)
+		case DrawDomino:
+			draw = &moves[i]
+		case EndTurn:
+			stop = &moves[i]
+		}
+	}
+
+	if g.UnresolvedDouble && len(plays) > 0 {
+		return plays[0], fmt.Sprintf(HintDouble, pathName(g.Trains[plays[0].PathID])), true
+	}
+
+	own := -1
+	for i, path := range g.Trains {
+		if path == p.Path {
+			own = i
+		}
+	}
+
+	chain, _ := dominos.LongestTrain(p.Hand, p.Path.End(g.Center), dominos.MostTiles)
+	inChain := func(d dominos.Domino) bool {
+		for _, c := range chain {
+			if sameTile(c, d) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var next, offChain, heaviest *Event
+	for i, e := range plays {
+		d := p.Hand[e.HandIndex]
+		switch {
+		case e.PathID == own && len(chain) > 0 && sameTile(d, chain[0]):
+			next = &plays[i]
+		case e.PathID != own && !inChain(d) && (offChain == nil || d.Value() > p.Hand[offChain.HandIndex].Value()):
+			offChain = &plays[i]
+		}
+
+		if heaviest == nil || d.Value() > p.Hand[heaviest.HandIndex].Value() {
+			heaviest = &plays[i]
+		}
+	}
+
+	switch {
+	case next != nil && p.Path.Train:
+		return *next, HintTakeDown, true
+	case offChain != nil:
+		return *offChain, fmt.Sprintf(HintOffChain, p.Hand[offChain.HandIndex].Value()), true
+	case next != nil:
+		return *next, HintChain, true
+	case heaviest != nil:
+		return *heaviest, HintHeaviest, true
+	case draw != nil:
+		return *draw, HintDraw, true
+	case stop != nil && g.Phase == BigTurn && g.Played:
+		return *stop, HintFinishBig, true
+	case stop != nil:
+		return *stop, HintNothing, true
+	}
+
+	return Event{}, "", false
+}
+
+// sameTile returns true if a and b are the same tile, either way around.
+func sameTile(a, b dominos.Domino) bool {
+	return a == b || (a.Left == b.Right && a.Right == b.Left)
+}
+
+// describe says what an event does for a hint.
+func (g *Game) describe(p *dominos.Player, e Event) string {
+	switch e.Action {
+	case PlayDomino:
+		return fmt.Sprintf("playing %s (tile %d) on %s (path %d)", p.Hand[e.HandIndex].Display(), e.HandIndex, pathName(g.Trains[e.PathID]), e.PathID)
+	case DrawDomino:
+		return "drawing a tile"
+	case Knock:
+		return "knocking"
+	case EndTurn:
+		return "ending your turn"
+	}
+
+	return "something else"
+}
@@ -0,0 +1,115 @@
+package game
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHint(t *testing.T) {
+	cases := []struct {
+		name  string
+		board string
+		want  string
+	}{
+		{
+			name: "open double",
+			board: `
+center [6||6]
+turn Xena
+Xena >> [6|1]
+Vic >> [6|3] [3||3] <!>
+M >>
+hand Xena: [1|4] [9|3]
+hand Vic: [2|2]
+`,
+			want: "playing [9|3] (tile 1) on Vic's train (path 1): it satisfies the open double on Vic's train",
+		},
+		{
+			name: "off the chain",
+			board: `
+center [6||6]
+turn Xena
+Xena >> [6|1]
+Vic >> [6|3]
+M >>
+hand Xena: [1|4] [4|2] [6|9]
+hand Vic: [2|2]
+`,
+			want: "playing [6|9] (tile 2) on the Mexican train (path 2): it gets rid of 15 pips",
+		},
+		{
+			name: "train up",
+			board: `
+center [6||6]
+turn Xena
+Xena >> [6|1] *
+Vic >> [6|3]
+M >>
+hand Xena: [1|4] [4|2] [6|9]
+hand Vic: [2|2]
+`,
+			want: "playing [1|4] (tile 0) on Xena's train (path 0): it takes your train down",
+		},
+		{
+			name: "nothing to play",
+			board: `
+center [6||6]
+turn Xena
+Xena >> [6|1]
+Vic >> [6|3]
+M >> [6|5]
+hand Xena: [2|4] [8|8]
+hand Vic: [2|2]
+pool: [0|0]
+`,
+			want: "drawing a tile: you have nothing to play",
+		},
+	}
+
+	for _, c := range cases {
+		g := fromBoard(t, c.board)
+		g.Rules = Standard
+
+		r, err := g.HandleEvent(&Event{Action: Hint, PlayerID: "Xena"})
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+
+		if !strings.Contains(r.UserMessage, c.want) {
+			t.Errorf("%s: wanted %q, got %q", c.name, c.want, r.UserMessage)
+		}
+	}
+}
+
+func TestHintLimits(t *testing.T) {
+	g := fromBoard(t, threeSeats)
+
+	_, err := g.HandleEvent(&Event{Action: Hint, PlayerID: "Vic"})
+	if err != ErrHintsDisabled {
+		t.Fatalf("wanted %v, got %v", ErrHintsDisabled, err)
+	}
+
+	g.Rules.Hints = 1
+	_, err = g.HandleEvent(&Event{Action: Hint, PlayerID: "Xena"})
+	if err != ErrNotYourTurn {
+		t.Fatalf("wanted %v, got %v", ErrNotYourTurn, err)
+	}
+
+	r, err := g.HandleEvent(&Event{Action: Hint, PlayerID: "Vic"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.GlobalMessage != HintTakenMsg {
+		t.Fatalf("everyone should hear about hints, got %q", r.GlobalMessage)
+	}
+
+	_, err = g.HandleEvent(&Event{Action: Hint, PlayerID: "Vic"})
+	if err != ErrNoHintsLeft {
+		t.Fatalf("wanted %v, got %v", ErrNoHintsLeft, err)
+	}
+
+	last := g.Log[len(g.Log)-1]
+	if last.PlayerID != "Vic" || last.Action != Hint {
+		t.Fatalf("the hint should be in the log, got %#v", last)
+	}
+}
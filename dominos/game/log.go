@@ -0,0 +1,51 @@
+package game
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/cetacean/magiism/dominos"
+)
+
+// Entry is a single line in the record of a game.
+type Entry struct {
+	Time  time.Time
+	Round int
+	Text  string
+
+	// PlayerID and Action say who did what. Entries about the game itself,
+	// such as a round ending, have no PlayerID.
+	PlayerID string
+	Action   Action
+}
+
+// logf adds an entry to the game's record.
+func (g *Game) logf(id string, a Action, format string, args ...interface{}) {
+	g.Log = append(g.Log, Entry{
+		Time:     clock(),
+		Round:    g.Round,
+		Text:     fmt.Sprintf(format, args...),
+		PlayerID: id,
+		Action:   a,
+	})
+}
+
+// Digest returns everything that happened since the given player last did
+// something, oldest first.
+func (g *Game) Digest(id string) []Entry {
+	i := len(g.Log)
+	for i > 0 && g.Log[i-1].PlayerID != id {
+		i--
+	}
+
+	return g.Log[i:]
+}
+
+// pathName describes a path for the log.
+func pathName(p *dominos.Path) string {
+	if p.MexicanTrain {
+		return "the Mexican train"
+	}
+
+	return p.Player + "'s train"
+}
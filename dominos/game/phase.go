@@ -0,0 +1,96 @@
+package game
+
+import (
+	"errors"
+)
+
+// Phase is a stage in the lifecycle of a Game.
+type Phase int
+
+// Possible phases of a game.
+const (
+	Lobby     Phase = iota // Players are gathering, nothing has been dealt yet.
+	BigTurn                // Every player takes their first turn of the round.
+	Playing                // Regular turns.
+	RoundOver              // Someone dominoed or the board is blocked.
+	MatchOver              // The last round has been scored.
+	Abandoned              // The game was given up before it finished.
+)
+
+// Phase errors
+var (
+	ErrNotStarted     = errors.New("game: the game has not started yet")
+	ErrRoundOver      = errors.New("game: the round is over")
+	ErrMatchOver      = errors.New("game: the match is over")
+	ErrAbandoned      = errors.New("game: the game was abandoned")
+	ErrBadTransition  = errors.New("game: invalid phase transition")
+	ErrRoundNotOver   = errors.New("game: the round is still being played")
+	ErrBoneyardEmpty  = errors.New("game: there are no tiles left to draw")
+	ErrAlreadyDrawn   = errors.New("game: you have already drawn this turn")
+	ErrBigTurnOwnPath = errors.New("game: you can only play on your own path during your big turn")
+)
+
+// String returns the name of the phase.
+func (p Phase) String() string {
+	switch p {
+	case Lobby:
+		return "lobby"
+	case BigTurn:
+		return "big turn"
+	case Playing:
+		return "playing"
+	case RoundOver:
+		return "round over"
+	case MatchOver:
+		return "match over"
+	case Abandoned:
+		return "abandoned"
+	}
+
+	return "unknown"
+}
+
+// transitions lists the phases that may follow each phase. MatchOver and
+// Abandoned are final.
+var transitions = map[Phase][]Phase{
+	Lobby:     {BigTurn, Abandoned},
+	BigTurn:   {Playing, RoundOver, Abandoned},
+	Playing:   {RoundOver, Abandoned},
+	RoundOver: {BigTurn, MatchOver, Abandoned},
+}
+
+// setPhase moves the game to the given phase if that is a valid transition.
+func (g *Game) setPhase(to Phase) error {
+	for _, next := range transitions[g.Phase] {
+		if next == to {
+			g.Phase = to
+			return nil
+		}
+	}
+
+	return ErrBadTransition
+}
+
+// phaseError returns why turn actions can't be taken in the current phase,
+// or nil if they can.
+func (g *Game) phaseError() error {
+	switch g.Phase {
+	case BigTurn, Playing:
+		return nil
+	case Lobby:
+		return ErrNotStarted
+	case RoundOver:
+		return ErrRoundOver
+	case MatchOver:
+		return ErrMatchOver
+	case Abandoned:
+		return ErrAbandoned
+	}
+
+	return ErrBadTransition
+}
+
+// Abandon gives up on the game. Finished games can't be abandoned.
+func (g *Game) Abandon() error {
+	return g.setPhase(Abandoned)
+}
@@ -0,0 +1,246 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/cetacean/magiism/dominos"
+)
+
+// fromBoard sets up a game in the middle of a round from a board fixture.
+func fromBoard(t *testing.T, board string) *Game {
+	dg, err := dominos.ParseBoard(board)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &Game{
+		Game:   dg,
+		Phase:  Playing,
+		Round:  1,
+		Rounds: 7,
+		Scores: map[string]int{},
+	}
+}
+
+func tile(left, right int) dominos.Domino {
+	return dominos.Domino{Left: left, Right: right}
+}
+
+// play has the given player place the given tile from their hand.
+func play(g *Game, id string, path int, d dominos.Domino) (*Response, error) {
+	p, _ := g.GetPlayerByID(id)
+	for i, hd := range p.Hand {
+		if hd == d {
+			return g.HandleEvent(&Event{Action: PlayDomino, PlayerID: id, PathID: path, HandIndex: i})
+		}
+	}
+
+	return nil, ErrInvalidHandIndex
+}
+
+func TestNewGamePhase(t *testing.T) {
+	g, err := New([]string{"Xena", "Vic"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if g.Phase != BigTurn {
+		t.Fatalf("new games should start with the big turn, not %s", g.Phase)
+	}
+
+	lobby := &Game{Game: g.Game}
+	_, err = lobby.HandleEvent(&Event{Action: DrawDomino, PlayerID: lobby.GetActivePlayer().ID})
+	if err != ErrNotStarted {
+		t.Fatalf("wanted %v, got %v", ErrNotStarted, err)
+	}
+}
+
+func TestBigTurn(t *testing.T) {
+	g := fromBoard(t, `
+center [6||6]
+Xena >>
+Vic >>
+M >>
+hand Xena: [6|1] [1|4] [3|3]
+hand Vic: [6|2] [5|5]
+pool: [0|0]
+`)
+	g.Phase = BigTurn
+
+	_, err := play(g, "Xena", 2, tile(6, 1))
+	if err != ErrBigTurnOwnPath {
+		t.Fatalf("wanted %v, got %v", ErrBigTurnOwnPath, err)
+	}
+
+	for _, d := range []dominos.Domino{{Left: 6, Right: 1}, {Left: 1, Right: 4}} {
+		_, err = play(g, "Xena", 0, d)
+		if err != nil {
+			t.Fatalf("playing %s: %v\n%s", d.Display(), err, g.Board())
+		}
+	}
+
+	_, err = g.HandleEvent(&Event{Action: EndTurn, PlayerID: "Xena"})
+	if err != ErrEndOfTurn {
+		t.Fatalf("wanted %v, got %v", ErrEndOfTurn, err)
+	}
+
+	_, err = play(g, "Vic", 1, tile(6, 2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = g.HandleEvent(&Event{Action: EndTurn, PlayerID: "Vic"})
+	if err != ErrEndOfTurn {
+		t.Fatalf("wanted %v, got %v", ErrEndOfTurn, err)
+	}
+
+	if g.Phase != Playing {
+		t.Fatalf("big turn should be over, phase is %s", g.Phase)
+	}
+}
+
+func TestBigTurnDouble(t *testing.T) {
+	g := fromBoard(t, `
+center [6||6]
+Xena >>
+Vic >>
+M >>
+hand Xena: [6|1] [1|1] [5|0]
+hand Vic: [6|2] [1|3] [4|4]
+pool: [0|0] [2|2]
+`)
+	g.Phase = BigTurn
+
+	for _, d := range []dominos.Domino{{Left: 6, Right: 1}, {Left: 1, Right: 1}} {
+		_, err := play(g, "Xena", 0, d)
+		if err != nil {
+			t.Fatalf("playing %s: %v\n%s", d.Display(), err, g.Board())
+		}
+	}
+
+	// The double is open, so Xena has to try drawing for it first.
+	for _, e := range g.LegalMoves("Xena") {
+		if e.Action == EndTurn {
+			t.Fatalf("ending the turn is legal with a double open")
+		}
+	}
+	r, err := g.HandleEvent(&Event{Action: EndTurn, PlayerID: "Xena"})
+	if err != nil || r.Success {
+		t.Fatalf("ended the turn with a double open: %v", err)
+	}
+
+	_, err = g.HandleEvent(&Event{Action: DrawDomino, PlayerID: "Xena"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = g.HandleEvent(&Event{Action: EndTurn, PlayerID: "Xena"})
+	if err != ErrEndOfTurn {
+		t.Fatalf("wanted %v, got %v", ErrEndOfTurn, err)
+	}
+
+	// Vic covers it for her before playing his own path.
+	_, err = play(g, "Vic", 1, tile(6, 2))
+	if err != dominos.ErrDanglingDouble {
+		t.Fatalf("wanted %v, got %v", dominos.ErrDanglingDouble, err)
+	}
+	_, err = play(g, "Vic", 0, tile(1, 3))
+	if err != nil {
+		t.Fatalf("covering the double: %v\n%s", err, g.Board())
+	}
+	_, err = play(g, "Vic", 1, tile(6, 2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = g.HandleEvent(&Event{Action: EndTurn, PlayerID: "Vic"})
+	if err != ErrEndOfTurn {
+		t.Fatalf("wanted %v, got %v", ErrEndOfTurn, err)
+	}
+
+	if g.Phase != Playing || g.UnresolvedDouble {
+		t.Fatalf("big turn should be over with nothing open, phase is %s\n%s", g.Phase, g.Board())
+	}
+}
+
+func TestRoundOver(t *testing.T) {
+	g := fromBoard(t, `
+center [6||6]
+turn Vic
+Xena >> [6|1] *
+Vic >> [6|3]
+M >> [6|4] [4||4] <!>
+hand Xena: [5|5] [1|0]
+hand Vic: [4|2]
+`)
+
+	_, err := play(g, "Vic", 2, tile(4, 2))
+	if err != ErrEndOfTurn {
+		t.Fatalf("wanted %v, got %v", ErrEndOfTurn, err)
+	}
+
+	if g.Phase != RoundOver {
+		t.Fatalf("Vic dominoed but the phase is %s", g.Phase)
+	}
+
+	if vic, ok := g.Scores["Vic"]; !ok || vic != 0 || g.Scores["Xena"] != 11 {
+		t.Fatalf("bad scores: %v", g.Scores)
+	}
+
+	_, err = g.HandleEvent(&Event{Action: DrawDomino, PlayerID: "Xena"})
+	if err != ErrRoundOver {
+		t.Fatalf("wanted %v, got %v", ErrRoundOver, err)
+	}
+
+	err = g.NextRound()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if g.Phase != BigTurn || g.Round != 2 {
+		t.Fatalf("wanted round 2 to start, got %s in round %d", g.Phase, g.Round)
+	}
+}
+
+func TestBlockedRound(t *testing.T) {
+	g := fromBoard(t, `
+center [6||6]
+Xena >> [6|1]
+Vic >> [6|3]
+hand Xena: [5|5]
+hand Vic: [2|2]
+`)
+
+	_, err := g.HandleEvent(&Event{Action: DrawDomino, PlayerID: "Xena"})
+	if err != ErrBoneyardEmpty {
+		t.Fatalf("wanted %v, got %v", ErrBoneyardEmpty, err)
+	}
+
+	for _, id := range []string{"Xena", "Vic"} {
+		_, err = g.HandleEvent(&Event{Action: EndTurn, PlayerID: id})
+		if err != ErrEndOfTurn {
+			t.Fatalf("%s: wanted %v, got %v", id, ErrEndOfTurn, err)
+		}
+	}
+
+	if g.Phase != RoundOver {
+		t.Fatalf("nobody can play but the phase is %s", g.Phase)
+	}
+
+	if !g.Trains[0].Train || !g.Trains[1].Train {
+		t.Fatalf("passing should have set trains:\n%s", g.Board())
+	}
+}
+
+func TestTransitions(t *testing.T) {
+	g := &Game{Phase: MatchOver}
+	if g.Abandon() != ErrBadTransition {
+		t.Fatalf("finished matches can't be abandoned")
+	}
+
+	g.Phase = Playing
+	if g.Abandon() != nil || g.Phase != Abandoned {
+		t.Fatalf("could not abandon a game in progress")
+	}
+
+	if g.NextRound() != ErrAbandoned {
+		t.Fatalf("abandoned games can't go on")
+	}
+}
@@ -0,0 +1,83 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/cetacean/magiism/dominos"
+)
+
+// ErrPlayerCount is returned when a game is started with too few or too many
+// players for its rules.
+var ErrPlayerCount = errors.New("game: wrong number of players for these rules")
+
+// ErrBadSet is returned for rules whose set is bigger than any that is dealt.
+var ErrBadSet = fmt.Errorf("game: sets go up to double-%d", dominos.MaxSet)
+
+// RuleSet is a named collection of options a game is played with.
+type RuleSet struct {
+	Name       string
+	MinPlayers int
+	MaxPlayers int
+
+	// Set is the highest double in the set of tiles, which is also one less
+	// than the number of rounds in a match. Zero picks a set that suits the
+	// number of players.
+	Set int
+
+	// Clock limits how long players may take. The zero value leaves games
+	// untimed.
+	Clock Clock
+
+	// Async turns the game into a play-by-post game.
+	Async Async
+
+	// Hints is how many hints every player may take each round. Zero turns
+	// them off.
+	Hints int
+
+	// NoTracker leaves the tile tracker out of players' views.
+	NoTracker bool
+}
+
+// Standard is the rule set used when nobody picks one.
+var Standard = RuleSet{
+	Name:       "standard",
+	MinPlayers: 2,
+	MaxPlayers: 8,
+	Hints:      3,
+}
+
+// Tournament is a rule set for competitive games, where players get no help
+// from the game.
+var Tournament = RuleSet{
+	Name:       "tournament",
+	MinPlayers: 2,
+	MaxPlayers: 8,
+	NoTracker:  true,
+}
+
+// RuleSets holds every rule set players can choose from by name.
+var RuleSets = map[string]RuleSet{
+	Standard.Name:   Standard,
+	PlayByPost.Name: PlayByPost,
+	Tournament.Name: Tournament,
+}
+
+// CheckPlayers returns ErrPlayerCount if n players can't play by these rules.
+func (rs RuleSet) CheckPlayers(n int) error {
+	if n < 1 || n < rs.MinPlayers || (rs.MaxPlayers > 0 && n > rs.MaxPlayers) {
+		return ErrPlayerCount
+	}
+
+	return nil
+}
+
+// CheckSet returns ErrBadSet if no set can be dealt by these rules.
+func (rs RuleSet) CheckSet() error {
+	if rs.Set < 0 || rs.Set > dominos.MaxSet {
+		return ErrBadSet
+	}
+
+	return nil
+}
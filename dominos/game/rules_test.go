@@ -0,0 +1,38 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/cetacean/magiism/dominos"
+)
+
+func TestBadPlayerCounts(t *testing.T) {
+	if _, err := New(nil); err != ErrPlayerCount {
+		t.Fatalf("wanted %v, got %v", ErrPlayerCount, err)
+	}
+
+	if _, err := dominos.NewGame(nil); err != dominos.ErrNoPlayers {
+		t.Fatalf("wanted %v, got %v", dominos.ErrNoPlayers, err)
+	}
+
+	rules := Standard
+	rules.Set = 3
+	if _, err := NewWithRules([]string{"Xena", "Vic"}, rules); err != dominos.ErrSetTooSmall {
+		t.Fatalf("wanted %v, got %v", dominos.ErrSetTooSmall, err)
+	}
+}
+
+func TestBadSets(t *testing.T) {
+	rules := Standard
+	for _, set := range []int{-1, dominos.MaxSet + 1} {
+		rules.Set = set
+		if _, err := NewWithRules([]string{"Xena", "Vic"}, rules); err != ErrBadSet {
+			t.Fatalf("set %d: wanted %v, got %v", set, ErrBadSet, err)
+		}
+	}
+
+	rules.Set = dominos.MaxSet
+	if _, err := NewWithRules([]string{"Xena", "Vic"}, rules); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,356 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/cetacean/magiism/dominos"
+)
+
+// SchemaVersion is the version of the saved game format written by Save.
+// Whenever the layout of a saved game changes, bump this and append a
+// migration that upgrades documents from the previous version.
+const SchemaVersion = 8
+
+// Save errors
+var (
+	ErrSaveTooNew       = errors.New("game: saved game was written by a newer version of magiism")
+	ErrSaveUnmigratable = errors.New("game: saved game is too old to be upgraded")
+	ErrCorruptSave      = errors.New("game: saved game is corrupt")
+)
+
+// migrations[i] upgrades a saved game document from version i to version
+// i+1 in place. A nil entry means there is no way forward from that version.
+// Migrations only look at the document as it was laid out at their version,
+// never through the types the game uses today, which keep changing.
+var migrations = []func(doc map[string]json.RawMessage) error{
+	// Unversioned documents are plain json dumps of Game, which never
+	// carried the boneyard or the players' hands.
+	0: nil,
+
+	// Version 2 added phases, rounds and scores.
+	1: func(doc map[string]json.RawMessage) error {
+		type tile struct{ Left, Right int }
+		var v1 struct {
+			Center   tile
+			TilePool []tile
+			Trains   []*struct{ Elements []tile }
+			Players  []struct{ Hand []tile }
+		}
+
+		data, _ := json.Marshal(doc)
+		err := json.Unmarshal(data, &v1)
+		if err != nil {
+			return ErrCorruptSave
+		}
+
+		// The set is worked out from every tile, as HighestDouble does.
+		highest := v1.Center.Left
+		check := func(ts []tile) {
+			for _, t := range ts {
+				if t.Left > highest {
+					highest = t.Left
+				}
+				if t.Right > highest {
+					highest = t.Right
+				}
+			}
+		}
+
+		phase := BigTurn
+		check(v1.TilePool)
+		for _, path := range v1.Trains {
+			if path != nil && len(path.Elements) > 0 {
+				phase = Playing
+				check(path.Elements)
+			}
+		}
+		for _, p := range v1.Players {
+			check(p.Hand)
+		}
+
+		doc["Phase"], _ = json.Marshal(phase)
+		doc["Round"], _ = json.Marshal(1)
+		doc["Rounds"], _ = json.Marshal(highest + 1)
+		doc["Scores"], _ = json.Marshal(map[string]int{})
+		return nil
+	},
+
+	// Version 3 added rule sets. Older games were all standard games.
+	2: func(doc map[string]json.RawMessage) error {
+		var rounds int
+		err := json.Unmarshal(doc["Rounds"], &rounds)
+		if err != nil {
+			return ErrCorruptSave
+		}
+
+		doc["Rules"], _ = json.Marshal(map[string]interface{}{
+			"Name":       Standard.Name,
+			"MinPlayers": Standard.MinPlayers,
+			"MaxPlayers": Standard.MaxPlayers,
+			"Set":        rounds - 1,
+		})
+		return nil
+	},
+
+	// Version 4 added seats. Nobody had left a game before, so everyone is
+	// still playing their own seat.
+	3: func(doc map[string]json.RawMessage) error {
+		doc["Seats"], _ = json.Marshal(map[string]Seat{})
+		return nil
+	},
+
+	// Version 5 added turn clocks. Older games are untimed, so there is
+	// nothing to fill in.
+	4: func(doc map[string]json.RawMessage) error {
+		return nil
+	},
+
+	// Version 6 added the game log. Nothing was recorded before that.
+	5: func(doc map[string]json.RawMessage) error {
+		doc["Log"], _ = json.Marshal([]Entry{})
+		return nil
+	},
+
+	// Version 7 added hints. Games under a rule set that has them get them
+	// from now on, nobody has taken one yet.
+	6: func(doc map[string]json.RawMessage) error {
+		var rules map[string]json.RawMessage
+		err := json.Unmarshal(doc["Rules"], &rules)
+		if err != nil || rules == nil {
+			return ErrCorruptSave
+		}
+
+		var name string
+		err = json.Unmarshal(rules["Name"], &name)
+		if err != nil {
+			return ErrCorruptSave
+		}
+
+		rules["Hints"], _ = json.Marshal(RuleSets[name].Hints)
+		doc["Rules"], _ = json.Marshal(rules)
+		doc["HintsUsed"], _ = json.Marshal(map[string]int{})
+		return nil
+	},
+
+	// Version 8 added the switch for the tile tracker. Older games keep it
+	// on, so there is nothing to fill in.
+	7: func(doc map[string]json.RawMessage) error {
+		return nil
+	},
+}
+
+// savedGame is the on-disk layout of a Game. Unlike the Response state it
+// includes hidden information, so it must never be sent to players.
+type savedGame struct {
+	Version int `json:"version"`
+
+	ID     string
+	Phase  Phase
+	Rules  RuleSet
+	Round  int
+	Rounds int
+	Scores map[string]int
+	Drawn  bool
+	Played bool
+	Passes int
+	Seats  map[string]Seat
+
+	HintsUsed map[string]int
+
+	TurnStarted time.Time
+	Warned      int
+	Banks       map[string]time.Duration
+	Log         []Entry
+
+	TilePool         []dominos.Domino
+	Trains           []*dominos.Path
+	Players          []savedPlayer
+	Center           dominos.Domino
+	UnresolvedDouble bool
+	ActivePlayer     int
+}
+
+// savedPlayer is a dominos.Player with its path pointer replaced by an index
+// into the game's trains.
+type savedPlayer struct {
+	ID      string
+	Hand    []dominos.Domino
+	BigPlay bool
+	Knocked bool
+	Path    int
+}
+
+// Save serializes the full game state, including hands and the tile pool,
+// tagged with the current SchemaVersion.
+func (g *Game) Save() ([]byte, error) {
+	sg := savedGame{
+		Version: SchemaVersion,
+
+		ID:     g.ID,
+		Phase:  g.Phase,
+		Rules:  g.Rules,
+		Round:  g.Round,
+		Rounds: g.Rounds,
+		Scores: g.Scores,
+		Drawn:  g.Drawn,
+		Played: g.Played,
+		Passes: g.Passes,
+		Seats:  g.Seats,
+
+		HintsUsed: g.HintsUsed,
+
+		TurnStarted: g.TurnStarted,
+		Warned:      g.Warned,
+		Banks:       g.Banks,
+		Log:         g.Log,
+
+		TilePool:         g.TilePool,
+		Trains:           g.Trains,
+		Center:           g.Center,
+		UnresolvedDouble: g.UnresolvedDouble,
+		ActivePlayer:     g.ActivePlayer,
+	}
+
+	for _, p := range g.Players {
+		sp := savedPlayer{
+			ID:      p.ID,
+			Hand:    p.Hand,
+			BigPlay: p.BigPlay,
+			Knocked: p.Knocked,
+			Path:    -1,
+		}
+
+		for i, path := range g.Trains {
+			if path == p.Path {
+				sp.Path = i
+			}
+		}
+
+		sg.Players = append(sg.Players, sp)
+	}
+
+	return json.Marshal(sg)
+}
+
+// Load restores a game written by Save, upgrading it from older schema
+// versions as needed.
+func Load(data []byte) (*Game, error) {
+	doc := map[string]json.RawMessage{}
+	err := json.Unmarshal(data, &doc)
+	if err != nil {
+		return nil, ErrCorruptSave
+	}
+
+	version := 0
+	if raw, ok := doc["version"]; ok {
+		err = json.Unmarshal(raw, &version)
+		if err != nil || version < 0 {
+			return nil, ErrCorruptSave
+		}
+	}
+
+	if version > SchemaVersion {
+		return nil, ErrSaveTooNew
+	}
+
+	for ; version < SchemaVersion; version++ {
+		if version >= len(migrations) || migrations[version] == nil {
+			return nil, ErrSaveUnmigratable
+		}
+		migrate := migrations[version]
+
+		err = migrate(doc)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	data, err = json.Marshal(doc)
+	if err != nil {
+		return nil, ErrCorruptSave
+	}
+
+	var sg savedGame
+	err = json.Unmarshal(data, &sg)
+	if err != nil {
+		return nil, ErrCorruptSave
+	}
+
+	return sg.restore()
+}
+
+// restore rebuilds a Game from its saved form, checking that every index in
+// it points somewhere valid.
+func (sg *savedGame) restore() (*Game, error) {
+	if len(sg.Players) == 0 || sg.ActivePlayer < 0 || sg.ActivePlayer >= len(sg.Players) {
+		return nil, ErrCorruptSave
+	}
+
+	if sg.Phase < Lobby || sg.Phase > Abandoned {
+		return nil, ErrCorruptSave
+	}
+
+	for _, path := range sg.Trains {
+		if path == nil {
+			return nil, ErrCorruptSave
+		}
+
+		for _, e := range path.Elements {
+			if e == nil {
+				return nil, ErrCorruptSave
+			}
+		}
+	}
+
+	dg := &dominos.Game{
+		TilePool:         sg.TilePool,
+		Trains:           sg.Trains,
+		Center:           sg.Center,
+		UnresolvedDouble: sg.UnresolvedDouble,
+		ActivePlayer:     sg.ActivePlayer,
+	}
+
+	for _, sp := range sg.Players {
+		if sp.Path < 0 || sp.Path >= len(sg.Trains) {
+			return nil, ErrCorruptSave
+		}
+
+		dg.Players = append(dg.Players, &dominos.Player{
+			ID:      sp.ID,
+			Hand:    sp.Hand,
+			BigPlay: sp.BigPlay,
+			Knocked: sp.Knocked,
+			Path:    sg.Trains[sp.Path],
+		})
+	}
+
+	g := &Game{
+		Game: dg,
+
+		ID:     sg.ID,
+		Phase:  sg.Phase,
+		Rules:  sg.Rules,
+		Round:  sg.Round,
+		Rounds: sg.Rounds,
+		Scores: sg.Scores,
+		Drawn:  sg.Drawn,
+		Played: sg.Played,
+		Passes: sg.Passes,
+		Seats:  sg.Seats,
+
+		HintsUsed: sg.HintsUsed,
+
+		TurnStarted: sg.TurnStarted,
+		Warned:      sg.Warned,
+		Banks:       sg.Banks,
+		Log:         sg.Log,
+	}
+
+	if g.Scores == nil {
+		g.Scores = map[string]int{}
+	}
+
+	return g, nil
+}
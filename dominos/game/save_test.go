@@ -0,0 +1,105 @@
+package game
+
+import (
+	"testing"
+)
+
+func TestSaveLoad(t *testing.T) {
+	g, err := New([]string{"Xena", "Vic"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := g.Save()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g2, err := Load(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(g2.Players) != len(g.Players) || len(g2.TilePool) != len(g.TilePool) {
+		t.Fatalf("loaded game differs from saved game")
+	}
+
+	for i, p := range g2.Players {
+		if p.Path != g2.Trains[i] {
+			t.Fatalf("player %s lost their path", p.ID)
+		}
+
+		if len(p.Hand) != len(g.Players[i].Hand) {
+			t.Fatalf("player %s hand has %d tiles, wanted %d", p.ID, len(p.Hand), len(g.Players[i].Hand))
+		}
+	}
+}
+
+func TestLoadBadSaves(t *testing.T) {
+	cases := []struct {
+		name string
+		data string
+		err  error
+	}{
+		{
+			name: "not json",
+			data: "Xena >> 0:[6|1]",
+			err:  ErrCorruptSave,
+		},
+		{
+			name: "unversioned",
+			data: `{"Trains":[],"Center":{"Left":6,"Right":6},"ID":"foo"}`,
+			err:  ErrSaveUnmigratable,
+		},
+		{
+			name: "from the future",
+			data: `{"version":9001}`,
+			err:  ErrSaveTooNew,
+		},
+		{
+			name: "rules missing",
+			data: `{"version":6,"Rules":null,"Trains":[],"Players":[]}`,
+			err:  ErrCorruptSave,
+		},
+		{
+			name: "dangling path index",
+			data: `{"version":8,"Trains":[],"Players":[{"ID":"Xena","Path":3}]}`,
+			err:  ErrCorruptSave,
+		},
+	}
+
+	for _, tcase := range cases {
+		t.Run(tcase.name, func(t *testing.T) {
+			_, err := Load([]byte(tcase.data))
+			if err != tcase.err {
+				t.Fatalf("got %v, wanted %v", err, tcase.err)
+			}
+		})
+	}
+}
+
+func TestMigrations(t *testing.T) {
+	if len(migrations) != SchemaVersion {
+		t.Fatalf("there are %d migrations for schema version %d", len(migrations), SchemaVersion)
+	}
+}
+
+func TestLoadVersion1(t *testing.T) {
+	data := `{"version":1,"ID":"foo","Center":{"Left":6,"Right":6},
+		"Trains":[{"Player":"Xena","Elements":[{"Left":6,"Right":1}]},{"Player":"Vic"}],
+		"Players":[{"ID":"Xena","Hand":[{"Left":9,"Right":2}],"Path":0},{"ID":"Vic","Path":1}],
+		"ActivePlayer":1}`
+
+	g, err := Load([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if g.Phase != Playing || g.Round != 1 || g.Rounds != 10 {
+		t.Fatalf("migration got phase %s, round %d of %d", g.Phase, g.Round, g.Rounds)
+	}
+
+	if g.Rules.Name != Standard.Name || g.Rules.Set != 9 || g.Rules.Hints != Standard.Hints {
+		t.Fatalf("migration got rules %#v", g.Rules)
+	}
+}
@@ -0,0 +1,218 @@
+package game
+
+import (
+	"errors"
+	"math/rand"
+
+	"github.com/cetacean/magiism/dominos"
+)
+
+// Seat errors
+var (
+	ErrUnknownPlayer    = errors.New("game: that player is not in this game")
+	ErrSeatTaken        = errors.New("game: that seat is still being played by a human")
+	ErrSeatGone         = errors.New("game: that seat has been given up")
+	ErrAlreadyPlaying   = errors.New("game: you are already playing in this game")
+	ErrUnknownDeparture = errors.New("game: unknown way of leaving the game")
+)
+
+// Seat describes who is playing a player's seat.
+type Seat int
+
+// Possible seats. Everyone starts out as Human.
+const (
+	Human  Seat = iota
+	Bot         // An AI picked by the frontend plays this seat.
+	Vacant      // Waiting for a human to take over, skipped until then.
+	Gone        // The player left and returned their tiles, skipped for good.
+)
+
+// Departure is what should happen to a player's seat when they leave.
+type Departure int
+
+// Possible ways to leave a game.
+const (
+	ReturnTiles Departure = iota // Shuffle the hand back into the boneyard.
+	HandToBot                    // Let an AI finish the game for them.
+	KeepSeat                     // Keep the hand so a human can take over.
+)
+
+// Possible messages to the client about seats.
+const (
+	LeftMsg       = "$EVENT_PLAYER_NAME has left the game, their train stays up"
+	BotSeatMsg    = "A bot has taken over $EVENT_PLAYER_NAME's seat"
+	VacantSeatMsg = "$EVENT_PLAYER_NAME's seat is open for someone to take over"
+)
+
+// SeatOf returns who is playing the given player's seat.
+func (g *Game) SeatOf(id string) Seat {
+	return g.Seats[id]
+}
+
+// skipped returns true if nobody takes turns for the given player.
+func (g *Game) skipped(id string) bool {
+	switch g.SeatOf(id) {
+	case Vacant, Gone:
+		return true
+	}
+
+	return false
+}
+
+// seated counts the seats that still take turns.
+func (g *Game) seated() int {
+	result := 0
+	for _, p := range g.Players {
+		if !g.skipped(p.ID) {
+			result++
+		}
+	}
+
+	return result
+}
+
+// Leave takes a player out of the game mid-round. Their path stays on the
+// board with its train up for good, and the game moves on without them if it
+// was their turn.
+func (g *Game) Leave(id string, how Departure) (*Response, error) {
+	err := g.phaseError()
+	if err != nil {
+		return nil, err
+	}
+
+	p, ok := g.GetPlayerByID(id)
+	if !ok {
+		return nil, ErrUnknownPlayer
+	}
+
+	if g.SeatOf(id) == Gone {
+		return nil, ErrSeatGone
+	}
+
+	r := &Response{
+		State:         g.Game,
+		PlayerID:      id,
+		Success:       true,
+		GlobalMessage: LeftMsg,
+	}
+
+	if g.Seats == nil {
+		g.Seats = map[string]Seat{}
+	}
+
+	switch how {
+	case ReturnTiles:
+		g.Seats[id] = Gone
+		g.returnTiles(p)
+
+	case HandToBot:
+		g.Seats[id] = Bot
+		r.GlobalMessage += "\n" + BotSeatMsg
+
+	case KeepSeat:
+		g.Seats[id] = Vacant
+		r.GlobalMessage += "\n" + VacantSeatMsg
+
+	default:
+		return nil, ErrUnknownDeparture
+	}
+
+	desert(p)
+	g.logf(id, Forfeit, "%s left the game", id)
+
+	if g.seated() == 0 {
+		g.setPhase(Abandoned)
+		return r, nil
+	}
+
+	if g.GetActivePlayer() == p && g.skipped(id) {
+		g.Drawn = false
+		g.Played = false
+		g.advance(r)
+	}
+
+	return r, nil
+}
+
+// desert puts the train of a player who left up for good.
+func desert(p *dominos.Player) {
+	p.Path.Train = true
+	p.Path.Deserted = true
+}
+
+// returnTiles shuffles a player's hand back into the boneyard.
+func (g *Game) returnTiles(p *dominos.Player) {
+	g.TilePool = append(g.TilePool, p.Hand...)
+	p.Hand = nil
+	p.Knocked = false
+
+	intn := rand.Intn
+	if g.Rand != nil {
+		intn = g.Rand.Intn
+	}
+	for i := range g.TilePool {
+		j := intn(i + 1)
+		g.TilePool[i], g.TilePool[j] = g.TilePool[j], g.TilePool[i]
+	}
+}
+
+// reseat carries the seats of players who left over to a newly dealt round.
+// Their trains go up again, and a vacant seat's hand goes back into the
+// boneyard, since nobody would play it.
+func (g *Game) reseat() {
+	for _, p := range g.Players {
+		switch g.SeatOf(p.ID) {
+		case Vacant:
+			g.returnTiles(p)
+			desert(p)
+		case Bot:
+			desert(p)
+		}
+	}
+
+	// Whoever holds the center double starts, unless nobody plays for them.
+	for i := 0; i < len(g.Players) && g.skipped(g.GetActivePlayer().ID); i++ {
+		g.ActivePlayer = (g.ActivePlayer + 1) % len(g.Players)
+	}
+}
+
+// TakeSeat lets a new human player take over a seat that was handed to a
+// bot or kept open. The player takes over the hand and path as they are, and
+// can take the train down again by playing on their own path. A seat that
+// was open when the round was dealt has no hand, so they draw a fresh one.
+func (g *Game) TakeSeat(seat, id string) error {
+	p, ok := g.GetPlayerByID(seat)
+	if !ok {
+		return ErrUnknownPlayer
+	}
+
+	switch g.SeatOf(seat) {
+	case Human:
+		return ErrSeatTaken
+	case Gone:
+		return ErrSeatGone
+	}
+
+	if _, ok := g.GetPlayerByID(id); ok {
+		return ErrAlreadyPlaying
+	}
+
+	g.logf(id, Forfeit, "%s took over %s's seat", id, seat)
+	p.ID = id
+	p.Path.Player = id
+	p.Path.Deserted = false
+	delete(g.Seats, seat)
+
+	if len(p.Hand) == 0 && (g.Phase == BigTurn || g.Phase == Playing) {
+		for i := 0; i < dominos.HandCount(len(g.Players)) && len(g.TilePool) > 0; i++ {
+			g.Draw(p)
+		}
+	}
+
+	if score, ok := g.Scores[seat]; ok {
+		delete(g.Scores, seat)
+		g.Scores[id] = score
+	}
+
+	return nil
+}
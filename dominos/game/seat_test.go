@@ -0,0 +1,144 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/cetacean/magiism/dominos"
+)
+
+const threeSeats = `
+center [6||6]
+turn Vic
+Xena >> [6|1]
+Vic >> [6|3]
+Gabrielle >> [6|2]
+M >>
+hand Xena: [1|4] [5|5]
+hand Vic: [3|5] [0|0]
+hand Gabrielle: [2|0] [3|3]
+pool: [4|4]
+`
+
+func TestLeaveReturnTiles(t *testing.T) {
+	g := fromBoard(t, threeSeats)
+
+	_, err := g.HandleEvent(&Event{Action: Forfeit, PlayerID: "Vic", Departure: ReturnTiles})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(g.TilePool) != 3 || g.GetActivePlayer().ID != "Gabrielle" {
+		t.Fatalf("Vic's tiles should be back in the boneyard and it should be Gabrielle's turn:\n%s", g.Board())
+	}
+
+	if !g.Trains[1].Train || !g.Trains[1].Deserted {
+		t.Fatalf("Vic's train should be up for good:\n%s", g.Board())
+	}
+
+	// Vic's seat is skipped from now on.
+	_, err = play(g, "Gabrielle", 2, tile(2, 0))
+	if err != ErrEndOfTurn {
+		t.Fatalf("wanted %v, got %v", ErrEndOfTurn, err)
+	}
+	if g.GetActivePlayer().ID != "Xena" {
+		t.Fatalf("wanted Xena to be up, got %s", g.GetActivePlayer().ID)
+	}
+
+	if err := g.TakeSeat("Vic", "Joxer"); err != ErrSeatGone {
+		t.Fatalf("wanted %v, got %v", ErrSeatGone, err)
+	}
+}
+
+func TestLeaveKeepSeat(t *testing.T) {
+	g := fromBoard(t, threeSeats)
+
+	_, err := g.HandleEvent(&Event{Action: Forfeit, PlayerID: "Xena", Departure: KeepSeat})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Xena is skipped while her seat is vacant.
+	_, err = play(g, "Vic", 1, tile(3, 5))
+	if err != ErrEndOfTurn {
+		t.Fatalf("wanted %v, got %v", ErrEndOfTurn, err)
+	}
+	_, err = play(g, "Gabrielle", 2, tile(2, 0))
+	if err != ErrEndOfTurn {
+		t.Fatalf("wanted %v, got %v", ErrEndOfTurn, err)
+	}
+	if g.GetActivePlayer().ID != "Vic" {
+		t.Fatalf("wanted Vic to be up, got %s", g.GetActivePlayer().ID)
+	}
+
+	if err := g.TakeSeat("Vic", "Joxer"); err != ErrSeatTaken {
+		t.Fatalf("wanted %v, got %v", ErrSeatTaken, err)
+	}
+	if err := g.TakeSeat("Xena", "Joxer"); err != nil {
+		t.Fatal(err)
+	}
+
+	p, ok := g.GetPlayerByID("Joxer")
+	if !ok || len(p.Hand) != 2 || p.Path.Player != "Joxer" || g.SeatOf("Joxer") != Human {
+		t.Fatalf("Joxer did not take over Xena's seat:\n%s", g.Board())
+	}
+}
+
+func TestEveryoneLeaves(t *testing.T) {
+	g := fromBoard(t, threeSeats)
+
+	for _, id := range []string{"Xena", "Vic", "Gabrielle"} {
+		_, err := g.Leave(id, KeepSeat)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if g.Phase != Abandoned {
+		t.Fatalf("wanted an abandoned game, got %s", g.Phase)
+	}
+}
+
+func TestLeaveNextRound(t *testing.T) {
+	g := fromBoard(t, threeSeats)
+
+	if _, err := g.Leave("Xena", KeepSeat); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.Leave("Vic", HandToBot); err != nil {
+		t.Fatal(err)
+	}
+
+	g.Phase = RoundOver
+	if err := g.NextRound(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, p := range g.Players[:2] {
+		if !p.Path.Train || !p.Path.Deserted {
+			t.Fatalf("%s's train should still be up for good:\n%s", p.ID, g.Board())
+		}
+	}
+	if g.Players[2].Path.Train || g.Players[2].Path.Deserted {
+		t.Fatalf("Gabrielle's train should be down:\n%s", g.Board())
+	}
+
+	// Nobody plays Xena's seat, so her tiles are in the boneyard.
+	xena := g.Players[0]
+	if len(xena.Hand) != 0 || g.GetActivePlayer() == xena {
+		t.Fatalf("Xena's vacant seat was dealt in:\n%s", g.Board())
+	}
+	tiles := len(g.TilePool) + 1
+	for _, p := range g.Players {
+		tiles += len(p.Hand)
+	}
+	if tiles != 55 {
+		t.Fatalf("wanted every tile of a double-9 set, got %d:\n%s", tiles, g.Board())
+	}
+
+	if err := g.TakeSeat("Xena", "Joxer"); err != nil {
+		t.Fatal(err)
+	}
+	if len(xena.Hand) != dominos.HandCount(len(g.Players)) {
+		t.Fatalf("Joxer should have been dealt a hand:\n%s", g.Board())
+	}
+}
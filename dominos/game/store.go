@@ -0,0 +1,123 @@
+package game
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// ErrGameNotFound is returned by a Store that doesn't have the game asked for.
+var ErrGameNotFound = errors.New("game: no such game")
+
+// FileStore is a Store that keeps every game as a file in a directory, so
+// that games survive restarts.
+type FileStore struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewFileStore creates a FileStore in the given directory, creating it if
+// needed.
+func NewFileStore(dir string) (*FileStore, error) {
+	err := os.MkdirAll(dir, 0700)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FileStore{dir: dir}, nil
+}
+
+// path returns where the game with the given ID is kept, or false if the ID
+// can't be used as a file name.
+func (fs *FileStore) path(id string) (string, bool) {
+	if id == "" || strings.HasPrefix(id, ".") || strings.ContainsAny(id, `/\`) {
+		return "", false
+	}
+
+	return filepath.Join(fs.dir, id+".json"), true
+}
+
+// GetGame loads a game.
+func (fs *FileStore) GetGame(id string) (*Game, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	fname, ok := fs.path(id)
+	if !ok {
+		return nil, ErrGameNotFound
+	}
+
+	data, err := ioutil.ReadFile(fname)
+	if os.IsNotExist(err) {
+		return nil, ErrGameNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return Load(data)
+}
+
+// PutGame saves a game. The file is replaced in one go, so a crash halfway
+// through never leaves a corrupt game behind.
+func (fs *FileStore) PutGame(id string, g *Game) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	fname, ok := fs.path(id)
+	if !ok {
+		return ErrGameNotFound
+	}
+
+	data, err := g.Save()
+	if err != nil {
+		return err
+	}
+
+	tmp := fname + ".tmp"
+	err = ioutil.WriteFile(tmp, data, 0600)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, fname)
+}
+
+// DeleteGame removes a game from the store.
+func (fs *FileStore) DeleteGame(id string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	fname, ok := fs.path(id)
+	if !ok {
+		return ErrGameNotFound
+	}
+
+	err := os.Remove(fname)
+	if os.IsNotExist(err) {
+		return ErrGameNotFound
+	}
+	return err
+}
+
+// Games lists the IDs of every game in the store, so that timed games can be
+// picked back up after a restart.
+func (fs *FileStore) Games() ([]string, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	names, err := filepath.Glob(filepath.Join(fs.dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var result []string
+	for _, name := range names {
+		result = append(result, strings.TrimSuffix(filepath.Base(name), ".json"))
+	}
+
+	return result, nil
+}
@@ -0,0 +1,58 @@
+package game
+
+import (
+	"github.com/cetacean/magiism/dominos"
+)
+
+// PipCount says where the tiles with a given pip value on them are, as far
+// as one player can tell.
+type PipCount struct {
+	Pip    int
+	Board  int // On the board, including the center.
+	Hand   int // In the player's own hand.
+	Unseen int // In someone else's hand or in the boneyard.
+}
+
+// track counts the tiles of every pip value in the set. It only looks at
+// what the view shows, so it can't give away anything hidden.
+func (v *View) track() []PipCount {
+	result := make([]PipCount, v.Set+1)
+	for i := range result {
+		result[i].Pip = i
+
+		// Every pip value is on one tile with each pip value, its double
+		// included.
+		result[i].Unseen = v.Set + 1
+	}
+
+	count := func(d dominos.Domino, where func(*PipCount)) {
+		for _, pip := range []int{d.Left, d.Right} {
+			if pip < 0 || pip >= len(result) {
+				return
+			}
+		}
+
+		where(&result[d.Left])
+		result[d.Left].Unseen--
+		if !d.IsDouble() {
+			where(&result[d.Right])
+			result[d.Right].Unseen--
+		}
+	}
+
+	board := func(pc *PipCount) { pc.Board++ }
+	hand := func(pc *PipCount) { pc.Hand++ }
+
+	count(v.Center, board)
+	for _, path := range v.Trains {
+		for _, e := range path.Elements {
+			count(e.Domino, board)
+		}
+	}
+
+	for _, d := range v.Hand {
+		count(d, hand)
+	}
+
+	return result
+}
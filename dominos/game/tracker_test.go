@@ -0,0 +1,70 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/cetacean/magiism/dominos"
+)
+
+func TestTracker(t *testing.T) {
+	g := fromBoard(t, threeSeats)
+
+	v, err := g.View("Xena")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(v.Tracker) != v.Set+1 {
+		t.Fatalf("wanted counts for pips 0 to %d, got %d", v.Set, len(v.Tracker))
+	}
+
+	// What Xena can't see has to be in someone else's hand or the pool.
+	for _, pc := range v.Tracker {
+		hidden := 0
+		for _, ds := range [][]dominos.Domino{g.Players[1].Hand, g.Players[2].Hand, g.TilePool} {
+			for _, d := range ds {
+				if d.Left == pc.Pip || d.Right == pc.Pip {
+					hidden++
+				}
+			}
+		}
+
+		if pc.Unseen < hidden {
+			t.Errorf("pip %d: %d unseen, but %d are hidden", pc.Pip, pc.Unseen, hidden)
+		}
+
+		if pc.Board+pc.Hand+pc.Unseen != v.Set+1 {
+			t.Errorf("pip %d: counts don't add up: %+v", pc.Pip, pc)
+		}
+	}
+
+	six := v.Tracker[6]
+	if six.Board != 4 || six.Hand != 0 || six.Unseen != v.Set-3 {
+		t.Fatalf("bad count for sixes: %+v", six)
+	}
+
+	five := v.Tracker[5]
+	if five.Board != 0 || five.Hand != 1 || five.Unseen != v.Set {
+		t.Fatalf("bad count for fives: %+v", five)
+	}
+
+	// How big the set is mustn't give away what others are holding.
+	g.Players[1].Hand = append(g.Players[1].Hand, dominos.Domino{Left: 9, Right: 9})
+	v, err = g.View("Xena")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Set != g.Rounds-1 {
+		t.Fatalf("wanted the set to be double-%d, got double-%d", g.Rounds-1, v.Set)
+	}
+
+	g.Rules = Tournament
+	v, err = g.View("Xena")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if v.Tracker != nil {
+		t.Fatal("tournament games shouldn't track tiles for players")
+	}
+}
@@ -0,0 +1,149 @@
+package game
+
+import (
+	"github.com/cetacean/magiism/dominos"
+)
+
+// View is what a single player can see of a game: the board, their own hand,
+// how many tiles everyone else holds and, when it is their turn, what they
+// may do. It shares nothing with the game, so it is safe to hand to bots.
+type View struct {
+	GameID   string
+	PlayerID string
+	Phase    Phase
+	Round    int
+	Rounds   int
+
+	Center           dominos.Domino
+	Trains           []*dominos.Path
+	UnresolvedDouble bool
+	Pool             int // Tiles left in the boneyard.
+	Set              int // Highest double in the set the round was dealt from.
+	Passes           int // Turns in a row that ended without a tile played.
+
+	Hand    []dominos.Domino
+	Players []PlayerView // In seat order.
+	Active  int          // Index into Players of whoever's turn it is.
+
+	// Tracker counts the tiles of every pip value, unless the rules turn it
+	// off.
+	Tracker []PipCount
+
+	// These are only meaningful on the viewer's own turn.
+	Drawn  bool
+	Played bool
+	Moves  []Event
+}
+
+// PlayerView is what everyone can see about a player.
+type PlayerView struct {
+	ID      string
+	Tiles   int
+	Knocked bool
+	Seat    Seat
+	Path    int // Index into Trains.
+	Score   int
+}
+
+// IsTurn returns true if it is the viewer's turn.
+func (v *View) IsTurn() bool {
+	return len(v.Players) > 0 && v.Players[v.Active].ID == v.PlayerID
+}
+
+// View returns the given player's view of the game.
+func (g *Game) View(id string) (*View, error) {
+	p, ok := g.GetPlayerByID(id)
+	if !ok {
+		return nil, ErrUnknownPlayer
+	}
+
+	v := &View{
+		GameID:   g.ID,
+		PlayerID: id,
+		Phase:    g.Phase,
+		Round:    g.Round,
+		Rounds:   g.Rounds,
+
+		Center:           g.Center,
+		UnresolvedDouble: g.UnresolvedDouble,
+		Pool:             len(g.TilePool),
+		Set:              g.Rounds - 1,
+		Passes:           g.Passes,
+
+		Hand:   append([]dominos.Domino(nil), p.Hand...),
+		Active: g.ActivePlayer,
+	}
+
+	for _, path := range g.Trains {
+		cp := *path
+		cp.Elements = nil
+		for _, e := range path.Elements {
+			ce := *e
+			cp.Elements = append(cp.Elements, &ce)
+		}
+
+		v.Trains = append(v.Trains, &cp)
+	}
+
+	for _, pl := range g.Players {
+		pv := PlayerView{
+			ID:      pl.ID,
+			Tiles:   len(pl.Hand),
+			Knocked: pl.Knocked,
+			Seat:    g.SeatOf(pl.ID),
+			Path:    -1,
+			Score:   g.Scores[pl.ID],
+		}
+
+		for i, path := range g.Trains {
+			if path == pl.Path {
+				pv.Path = i
+			}
+		}
+
+		v.Players = append(v.Players, pv)
+	}
+
+	if !g.Rules.NoTracker {
+		v.Tracker = v.track()
+	}
+
+	if v.IsTurn() {
+		v.Drawn = g.Drawn
+		v.Played = g.Played
+		v.Moves = g.LegalMoves(id)
+	}
+
+	return v, nil
+}
+
+// LegalMoves lists every event the given player could send right now that
+// the game would accept. It is empty when it isn't their turn.
+func (g *Game) LegalMoves(id string) []Event {
+	if g.phaseError() != nil || g.GetActivePlayer().ID != id {
+		return nil
+	}
+
+	p := g.GetActivePlayer()
+
+	var result []Event
+	plays := g.plays(p)
+	for _, e := range plays {
+		result = append(result, *e)
+	}
+
+	if !g.Drawn && len(g.TilePool) > 0 {
+		result = append(result, Event{Action: DrawDomino, PlayerID: id})
+	}
+
+	// Mirrors the checks done when handling EndTurn.
+	if g.bigTurnDone() || (len(plays) == 0 && !g.mustDraw()) {
+		result = append(result, Event{Action: EndTurn, PlayerID: id})
+	}
+
+	if len(p.Hand) == 1 && !p.Knocked {
+		result = append(result, Event{Action: Knock, PlayerID: id})
+	}
+
+	return result
+}
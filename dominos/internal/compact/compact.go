@@ -0,0 +1,425 @@
+// Package compact is a small, flat representation of a round of Mexican
+// Train for bots that search through many positions. Tiles are small
+// integers, hands and the boneyard are bitsets and paths only remember their
+// open end, so a board can be copied in one go and moves can be made and
+// taken back without allocating. Only a board made with Recorded, which can
+// be turned back into a public game, keeps a history of its moves.
+package compact
+
+import (
+	"errors"
+	"math/bits"
+
+	"github.com/cetacean/magiism/dominos"
+)
+
+// MaxSet is the highest double a compact board can hold.
+const MaxSet = 21
+
+// Conversion errors
+var (
+	// ErrTooBig is returned when a game uses tiles beyond MaxSet or has too
+	// many players to keep track of.
+	ErrTooBig = errors.New("compact: game is too big")
+
+	// ErrNotRecorded is returned when turning a board that wasn't made with
+	// Recorded back into a public game.
+	ErrNotRecorded = errors.New("compact: board isn't recorded")
+)
+
+// Tile is a tile's ID. The tile with sides a >= b is a*(a+1)/2 + b, so every
+// set is numbered from zero up and a smaller set keeps the same numbers.
+type Tile uint8
+
+var (
+	tileSides [256][2]int8
+	pipTiles  [MaxSet + 1]Set // Every tile with a given pip on it.
+)
+
+func init() {
+	for a := 0; a <= MaxSet; a++ {
+		for b := 0; b <= a; b++ {
+			t := ID(dominos.Domino{Left: a, Right: b})
+			tileSides[t] = [2]int8{int8(a), int8(b)}
+			pipTiles[a].Add(t)
+			pipTiles[b].Add(t)
+		}
+	}
+}
+
+// ID returns the ID of a tile, whichever way around it is.
+func ID(d dominos.Domino) Tile {
+	a, b := d.Left, d.Right
+	if b > a {
+		a, b = b, a
+	}
+
+	return Tile(a*(a+1)/2 + b)
+}
+
+// Domino returns the tile with its higher side on the left.
+func (t Tile) Domino() dominos.Domino {
+	return dominos.Domino{Left: int(tileSides[t][0]), Right: int(tileSides[t][1])}
+}
+
+// Other returns the side of the tile opposite the given pip.
+func (t Tile) Other(pip int) int {
+	if int(tileSides[t][0]) == pip {
+		return int(tileSides[t][1])
+	}
+
+	return int(tileSides[t][0])
+}
+
+// IsDouble returns true if both sides of the tile are the same.
+func (t Tile) IsDouble() bool {
+	return tileSides[t][0] == tileSides[t][1]
+}
+
+// Value returns how many pips are on the tile.
+func (t Tile) Value() int {
+	return int(tileSides[t][0]) + int(tileSides[t][1])
+}
+
+// Set is a set of tiles.
+type Set [4]uint64
+
+// Add puts a tile in the set.
+func (s *Set) Add(t Tile) {
+	s[t>>6] |= 1 << (t & 63)
+}
+
+// Remove takes a tile out of the set.
+func (s *Set) Remove(t Tile) {
+	s[t>>6] &^= 1 << (t & 63)
+}
+
+// Has returns true if the tile is in the set.
+func (s Set) Has(t Tile) bool {
+	return s[t>>6]&(1<<(t&63)) != 0
+}
+
+// Len counts the tiles in the set.
+func (s Set) Len() int {
+	return bits.OnesCount64(s[0]) + bits.OnesCount64(s[1]) + bits.OnesCount64(s[2]) + bits.OnesCount64(s[3])
+}
+
+// And returns the tiles in both sets.
+func (s Set) And(o Set) Set {
+	return Set{s[0] & o[0], s[1] & o[1], s[2] & o[2], s[3] & o[3]}
+}
+
+// With returns the tiles in the set that have the given pip on them.
+func (s Set) With(pip int) Set {
+	return s.And(pipTiles[pip])
+}
+
+// Tiles appends every tile in the set to dst, lowest ID first.
+func (s Set) Tiles(dst []Tile) []Tile {
+	for i, word := range s {
+		for word != 0 {
+			dst = append(dst, Tile(i<<6+bits.TrailingZeros64(word)))
+			word &= word - 1
+		}
+	}
+
+	return dst
+}
+
+// Value adds up the pips on every tile in the set.
+func (s Set) Value() int {
+	result := 0
+	for i, word := range s {
+		for word != 0 {
+			result += Tile(i<<6 + bits.TrailingZeros64(word)).Value()
+			word &= word - 1
+		}
+	}
+
+	return result
+}
+
+// Path is a path reduced to what matters for playing on it.
+type Path struct {
+	End   int8 // The pip the next tile has to match.
+	Len   int16
+	Owner int8 // Seat of the player whose path it is, or -1.
+
+	Train            bool
+	MexicanTrain     bool
+	UnresolvedDouble bool
+	Deserted         bool
+}
+
+// Move is a tile played on a path.
+type Move struct {
+	Tile Tile
+	Path int8
+}
+
+// Undo holds what a move changed, to take it back.
+type Undo struct {
+	path       Path
+	unresolved bool
+}
+
+// step is a single change made since a Recorded board was converted, for
+// rebuilding the public game.
+type step struct {
+	seat int8
+	draw bool
+	move Move
+}
+
+// Game is a round on a compact board. Seats are numbered in the order of
+// the players of the game it was made from.
+type Game struct {
+	Center Tile
+	Pool   Set
+	Hands  []Set
+	Paths  []Path
+
+	Active           int
+	UnresolvedDouble bool
+	Knocked          uint64 // One bit per seat.
+	BigPlay          uint64 // One bit per seat.
+
+	base    *dominos.Game // Only set on a Recorded board.
+	history []step
+}
+
+// Recorded is FromGame for a board that will be turned back into a public
+// game with ToGame. It keeps every move and draw made on it to do that,
+// which costs an allocation now and then.
+func Recorded(dg *dominos.Game) (*Game, error) {
+	g, err := FromGame(dg)
+	if err != nil {
+		return nil, err
+	}
+
+	g.base = dg.Clone()
+	return g, nil
+}
+
+// FromGame converts a game to a compact board, which can't be turned back
+// into a public game.
+func FromGame(dg *dominos.Game) (*Game, error) {
+	if len(dg.Players) > 64 {
+		return nil, ErrTooBig
+	}
+
+	tooBig := false
+	id := func(d dominos.Domino) Tile {
+		if d.Left < 0 || d.Right < 0 || d.Left > MaxSet || d.Right > MaxSet {
+			tooBig = true
+			return 0
+		}
+		return ID(d)
+	}
+
+	g := &Game{
+		Center:           id(dg.Center),
+		Active:           dg.ActivePlayer,
+		UnresolvedDouble: dg.UnresolvedDouble,
+	}
+
+	for _, d := range dg.TilePool {
+		g.Pool.Add(id(d))
+	}
+
+	for i, p := range dg.Players {
+		var hand Set
+		for _, d := range p.Hand {
+			hand.Add(id(d))
+		}
+		g.Hands = append(g.Hands, hand)
+
+		if p.Knocked {
+			g.Knocked |= 1 << uint(i)
+		}
+		if p.BigPlay {
+			g.BigPlay |= 1 << uint(i)
+		}
+	}
+
+	for _, path := range dg.Trains {
+		cp := Path{
+			End:   int8(path.End(dg.Center)),
+			Len:   int16(len(path.Elements)),
+			Owner: -1,
+
+			Train:            path.Train,
+			MexicanTrain:     path.MexicanTrain,
+			UnresolvedDouble: path.UnresolvedDouble,
+			Deserted:         path.Deserted,
+		}
+
+		for i, p := range dg.Players {
+			if p.Path == path {
+				cp.Owner = int8(i)
+			}
+		}
+
+		for _, e := range path.Elements {
+			id(e.Domino)
+		}
+
+		g.Paths = append(g.Paths, cp)
+	}
+
+	if tooBig {
+		return nil, ErrTooBig
+	}
+
+	return g, nil
+}
+
+// ToGame converts a Recorded board back to a public game, by making every
+// move since it was converted on a copy of the game it was made from. Any
+// other board returns ErrNotRecorded.
+func (g *Game) ToGame() (*dominos.Game, error) {
+	if g.base == nil {
+		return nil, ErrNotRecorded
+	}
+
+	dg := g.base.Clone()
+
+	take := func(ds []dominos.Domino, t Tile) []dominos.Domino {
+		for i, d := range ds {
+			if ID(d) == t {
+				return append(ds[:i], ds[i+1:]...)
+			}
+		}
+		return ds
+	}
+
+	for _, st := range g.history {
+		p := dg.Players[st.seat]
+		t := st.move.Tile
+
+		if st.draw {
+			dg.TilePool = take(dg.TilePool, t)
+			p.Hand = append(p.Hand, t.Domino())
+			continue
+		}
+
+		d := t.Domino()
+		for i, hd := range p.Hand {
+			if ID(hd) == t {
+				d, _ = p.RemoveFromHand(i)
+				break
+			}
+		}
+
+		// Tiles are laid the way dominos.Game.CanPlace lays them.
+		path := dg.Trains[st.move.Path]
+		path.Elements = append(path.Elements, &dominos.Element{
+			Domino:  d,
+			Flipped: d.Left != path.End(dg.Center),
+		})
+	}
+
+	dg.ActivePlayer = g.Active
+	dg.UnresolvedDouble = g.UnresolvedDouble
+	for i, p := range dg.Players {
+		p.Knocked = g.Knocked&(1<<uint(i)) != 0
+		p.BigPlay = g.BigPlay&(1<<uint(i)) != 0
+	}
+	for i, path := range dg.Trains {
+		path.Train = g.Paths[i].Train
+		path.UnresolvedDouble = g.Paths[i].UnresolvedDouble
+		path.Deserted = g.Paths[i].Deserted
+	}
+
+	return dg, nil
+}
+
+// Clone returns a copy of the board that shares nothing with it. The copy
+// isn't recorded, even if the board is.
+func (g *Game) Clone() *Game {
+	cp := *g
+	cp.Hands = append([]Set(nil), g.Hands...)
+	cp.Paths = append([]Path(nil), g.Paths...)
+	cp.base, cp.history = nil, nil
+
+	return &cp
+}
+
+// CanPlay returns true if the seat may play on the path at all, going by the
+// same rules as dominos.Game.CanPlace.
+func (g *Game) CanPlay(seat, path int) bool {
+	p := g.Paths[path]
+	if g.UnresolvedDouble {
+		return p.UnresolvedDouble
+	}
+
+	return int(p.Owner) == seat || p.Train || p.MexicanTrain
+}
+
+// Moves appends every tile the seat could play, and where, to dst.
+func (g *Game) Moves(dst []Move, seat int) []Move {
+	var buf [64]Tile
+
+	for i, p := range g.Paths {
+		if !g.CanPlay(seat, i) {
+			continue
+		}
+
+		for _, t := range g.Hands[seat].With(int(p.End)).Tiles(buf[:0]) {
+			dst = append(dst, Move{Tile: t, Path: int8(i)})
+		}
+	}
+
+	return dst
+}
+
+// Play makes a move for the seat, which has to be legal. It returns what is
+// needed to take it back with Unplay.
+func (g *Game) Play(seat int, m Move) Undo {
+	p := &g.Paths[m.Path]
+	u := Undo{path: *p, unresolved: g.UnresolvedDouble}
+
+	g.Hands[seat].Remove(m.Tile)
+	p.End = int8(m.Tile.Other(int(p.End)))
+	p.Len++
+
+	if int(p.Owner) == seat && p.Train && !p.Deserted {
+		p.Train = false
+	}
+
+	g.UnresolvedDouble = m.Tile.IsDouble()
+	p.UnresolvedDouble = m.Tile.IsDouble()
+
+	if g.base != nil {
+		g.history = append(g.history, step{seat: int8(seat), move: m})
+	}
+	return u
+}
+
+// Unplay takes back the last move made with Play.
+func (g *Game) Unplay(seat int, m Move, u Undo) {
+	g.Paths[m.Path] = u.path
+	g.UnresolvedDouble = u.unresolved
+	g.Hands[seat].Add(m.Tile)
+	if g.base != nil {
+		g.history = g.history[:len(g.history)-1]
+	}
+}
+
+// Draw moves a tile from the boneyard to the seat's hand.
+func (g *Game) Draw(seat int, t Tile) {
+	g.Pool.Remove(t)
+	g.Hands[seat].Add(t)
+	if g.base != nil {
+		g.history = append(g.history, step{seat: int8(seat), draw: true, move: Move{Tile: t}})
+	}
+}
+
+// Undraw takes back the last draw made with Draw.
+func (g *Game) Undraw(seat int, t Tile) {
+	g.Hands[seat].Remove(t)
+	g.Pool.Add(t)
+	if g.base != nil {
+		g.history = g.history[:len(g.history)-1]
+	}
+}
@@ -0,0 +1,197 @@
+package compact
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/cetacean/magiism/dominos"
+)
+
+func TestTileIDs(t *testing.T) {
+	want := Tile(0)
+	for a := 0; a <= MaxSet; a++ {
+		for b := 0; b <= a; b++ {
+			d := dominos.Domino{Left: a, Right: b}
+			if ID(d) != want || ID(dominos.Domino{Left: b, Right: a}) != want {
+				t.Fatalf("%s should be tile %d, got %d", d.Display(), want, ID(d))
+			}
+
+			if want.Domino() != d || want.Value() != d.Value() || want.IsDouble() != d.IsDouble() {
+				t.Fatalf("tile %d is not %s", want, d.Display())
+			}
+
+			want++
+		}
+	}
+}
+
+func TestSet(t *testing.T) {
+	var s Set
+	for _, tile := range []Tile{0, 63, 64, 200, 252} {
+		s.Add(tile)
+	}
+	s.Remove(63)
+
+	if s.Len() != 4 || s.Has(63) || !s.Has(200) {
+		t.Fatalf("bad set: %v", s.Tiles(nil))
+	}
+
+	if got := s.Tiles(nil); !reflect.DeepEqual(got, []Tile{0, 64, 200, 252}) {
+		t.Fatalf("bad tiles: %v", got)
+	}
+
+	value := Tile(64).Value() + Tile(200).Value() + Tile(252).Value()
+	if s.Value() != value {
+		t.Fatalf("wanted %d pips, got %d", value, s.Value())
+	}
+}
+
+// publicMoves lists every move the public game allows the seat.
+func publicMoves(dg *dominos.Game, seat int) map[Move]bool {
+	result := map[Move]bool{}
+	p := dg.Players[seat]
+	for _, d := range p.Hand {
+		for i, path := range dg.Trains {
+			if _, err := dg.CanPlace(p, d, path); err == nil {
+				result[Move{Tile: ID(d), Path: int8(i)}] = true
+			}
+		}
+	}
+
+	return result
+}
+
+// sameGame fails the test if the recorded board doesn't turn back into dg.
+func sameGame(t *testing.T, g *Game, dg *dominos.Game, what string) {
+	t.Helper()
+
+	got, err := g.ToGame()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Code() != dg.Code() {
+		t.Fatalf("%s lost something:\n%s\n%s", what, dg.Board(), got.Board())
+	}
+}
+
+func TestAgainstPublicGame(t *testing.T) {
+	r := rand.New(rand.NewSource(42))
+
+	for n := 0; n < 50; n++ {
+		dg, err := dominos.NewGameRand([]string{"Xena", "Vic", "Gabrielle"}, 12, r)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		g, err := Recorded(dg)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		sameGame(t, g, dg, "conversion")
+
+		for turn := 0; turn < 200 && g.Hands[g.Active].Len() > 0; turn++ {
+			seat := g.Active
+			moves := g.Moves(nil, seat)
+
+			want := publicMoves(dg, seat)
+			if len(moves) != len(want) {
+				t.Fatalf("wanted %d moves, got %v\n%s", len(want), moves, dg.Board())
+			}
+			for _, m := range moves {
+				if !want[m] {
+					t.Fatalf("%v isn't legal\n%s", m, dg.Board())
+				}
+			}
+
+			if len(moves) == 0 {
+				if g.Pool.Len() > 0 {
+					tile := ID(dg.TilePool[0])
+					g.Draw(seat, tile)
+					dg.Draw(dg.Players[seat])
+				} else {
+					g.Paths[seat].Train = true
+					dg.Trains[seat].Train = true
+				}
+			} else {
+				m := moves[r.Intn(len(moves))]
+
+				before, steps := g.Clone(), len(g.history)
+				u := g.Play(seat, m)
+				g.Unplay(seat, m, u)
+				after := g.Clone()
+				if len(g.history) != steps {
+					t.Fatalf("unplaying %v didn't take it out of the history", m)
+				}
+				if !reflect.DeepEqual(after, before) {
+					t.Fatalf("unplaying %v didn't put things back", m)
+				}
+
+				g.Play(seat, m)
+				p := dg.Players[seat]
+				for i, d := range p.Hand {
+					if ID(d) == m.Tile {
+						p.RemoveFromHand(i)
+						break
+					}
+				}
+				if err := dg.Place(p, m.Tile.Domino(), dg.Trains[m.Path]); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if g.UnresolvedDouble {
+				continue
+			}
+
+			g.Active = (seat + 1) % len(g.Hands)
+			dg.ActivePlayer = g.Active
+		}
+
+		sameGame(t, g, dg, "rebuilding")
+	}
+}
+
+func TestNoAllocs(t *testing.T) {
+	dg, err := dominos.NewGameRand([]string{"Xena", "Vic"}, 12, rand.New(rand.NewSource(42)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g, err := FromGame(dg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var seat int
+	var moves []Move
+	for seat = range g.Hands {
+		if moves = g.Moves(nil, seat); len(moves) > 0 {
+			break
+		}
+	}
+	if len(moves) == 0 {
+		t.Fatalf("nothing to play\n%s", dg.Board())
+	}
+	pool := g.Pool.Tiles(nil)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		u := g.Play(seat, moves[0])
+		g.Unplay(seat, moves[0], u)
+		g.Draw(seat, pool[0])
+		g.Undraw(seat, pool[0])
+	})
+	if allocs != 0 {
+		t.Fatalf("making and taking back moves allocated %v times", allocs)
+	}
+
+	g.Play(seat, moves[0])
+	g.Draw(seat, pool[0])
+	if g.history != nil {
+		t.Fatalf("a board that isn't recorded kept %d steps", len(g.history))
+	}
+	if _, err := g.ToGame(); err != ErrNotRecorded {
+		t.Fatalf("wanted %v, got %v", ErrNotRecorded, err)
+	}
+}
@@ -0,0 +1,169 @@
+// Package lobby gathers players into a table before a game of Mexican Train
+// is dealt.
+package lobby
+
+import (
+	"errors"
+
+	"github.com/Xe/uuid"
+	"github.com/cetacean/magiism/dominos/game"
+)
+
+// Lobby errors
+var (
+	ErrAlreadyJoined = errors.New("lobby: you are already in this lobby")
+	ErrNotInLobby    = errors.New("lobby: you are not in this lobby")
+	ErrLobbyFull     = errors.New("lobby: this lobby is full")
+	ErrLobbyClosed   = errors.New("lobby: this lobby has closed")
+	ErrNotHost       = errors.New("lobby: only the host can do that")
+	ErrNotReady      = errors.New("lobby: not everyone is ready")
+)
+
+// Lobby gathers a table of players before a game starts. The host picks the
+// rules, everyone readies up and then the host starts the game.
+type Lobby struct {
+	ID      string
+	Host    string
+	Players []string // In seat order.
+	Ready   map[string]bool
+	Rules   game.RuleSet
+
+	// Closed is set once the game has started or everyone has left.
+	Closed bool
+}
+
+// New opens a lobby with host as its first player.
+func New(host string) *Lobby {
+	return &Lobby{
+		ID:      uuid.New(),
+		Host:    host,
+		Players: []string{host},
+		Ready:   map[string]bool{},
+		Rules:   game.Standard,
+	}
+}
+
+func (l *Lobby) has(id string) bool {
+	for _, p := range l.Players {
+		if p == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Join seats a player at the end of the table.
+func (l *Lobby) Join(id string) error {
+	switch {
+	case l.Closed:
+		return ErrLobbyClosed
+	case l.has(id):
+		return ErrAlreadyJoined
+	case l.Rules.MaxPlayers > 0 && len(l.Players) >= l.Rules.MaxPlayers:
+		return ErrLobbyFull
+	}
+
+	l.Players = append(l.Players, id)
+	return nil
+}
+
+// Leave removes a player from the table. If the host leaves, the next player
+// in line becomes the host. The lobby closes when the last player leaves.
+func (l *Lobby) Leave(id string) error {
+	if l.Closed {
+		return ErrLobbyClosed
+	}
+
+	for i, p := range l.Players {
+		if p != id {
+			continue
+		}
+
+		l.Players = append(l.Players[:i], l.Players[i+1:]...)
+		delete(l.Ready, id)
+
+		if len(l.Players) == 0 {
+			l.Closed = true
+		} else if l.Host == id {
+			l.Host = l.Players[0]
+		}
+
+		return nil
+	}
+
+	return ErrNotInLobby
+}
+
+// SetRules changes the rules of the game to be played. Everyone has to ready
+// up again afterwards, so nobody starts a game they didn't agree to.
+func (l *Lobby) SetRules(by string, rules game.RuleSet) error {
+	switch {
+	case l.Closed:
+		return ErrLobbyClosed
+	case by != l.Host:
+		return ErrNotHost
+	}
+
+	err := rules.CheckSet()
+	if err != nil {
+		return err
+	}
+
+	l.Rules = rules
+	l.Ready = map[string]bool{}
+	return nil
+}
+
+// SetSize changes the highest double of the set to be dealt. Zero picks one
+// that suits the number of players.
+func (l *Lobby) SetSize(by string, set int) error {
+	rules := l.Rules
+	rules.Set = set
+	return l.SetRules(by, rules)
+}
+
+// SetReady marks a player as ready to start, or not.
+func (l *Lobby) SetReady(id string, ready bool) error {
+	switch {
+	case l.Closed:
+		return ErrLobbyClosed
+	case !l.has(id):
+		return ErrNotInLobby
+	}
+
+	l.Ready[id] = ready
+	return nil
+}
+
+// Start deals the game with the players in their current seats. Only the
+// host can start the game, and only once everyone is ready.
+func (l *Lobby) Start(by string) (*game.Game, error) {
+	switch {
+	case l.Closed:
+		return nil, ErrLobbyClosed
+	case by != l.Host:
+		return nil, ErrNotHost
+	}
+
+	err := l.Rules.CheckPlayers(len(l.Players))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range l.Players {
+		if !l.Ready[p] {
+			return nil, ErrNotReady
+		}
+	}
+
+	g, err := game.NewWithRules(l.Players, l.Rules)
+	if err != nil {
+		return nil, err
+	}
+
+	g.ID = l.ID
+	l.Closed = true
+
+	return g, nil
+}
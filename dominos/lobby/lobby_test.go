@@ -0,0 +1,72 @@
+package lobby
+
+import (
+	"testing"
+
+	"github.com/cetacean/magiism/dominos/game"
+)
+
+func TestLobby(t *testing.T) {
+	l := New("Xena")
+
+	if _, err := l.Start("Xena"); err != game.ErrPlayerCount {
+		t.Fatalf("a table of one should not start, got %v", err)
+	}
+
+	for _, id := range []string{"Vic", "Gabrielle", "Joxer"} {
+		if err := l.Join(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := l.Join("Vic"); err != ErrAlreadyJoined {
+		t.Fatalf("wanted %v, got %v", ErrAlreadyJoined, err)
+	}
+
+	if err := l.Leave("Joxer"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := l.SetSize("Vic", 12); err != ErrNotHost {
+		t.Fatalf("wanted %v, got %v", ErrNotHost, err)
+	}
+	for _, set := range []int{-1, 19} {
+		if err := l.SetSize("Xena", set); err != game.ErrBadSet {
+			t.Fatalf("set %d: wanted %v, got %v", set, game.ErrBadSet, err)
+		}
+	}
+	if err := l.SetSize("Xena", 12); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, id := range l.Players {
+		l.SetReady(id, true)
+	}
+	l.SetReady("Gabrielle", false)
+
+	if _, err := l.Start("Xena"); err != ErrNotReady {
+		t.Fatalf("wanted %v, got %v", ErrNotReady, err)
+	}
+
+	// The host leaving hands the lobby to the next player.
+	if err := l.Leave("Xena"); err != nil {
+		t.Fatal(err)
+	}
+	if l.Host != "Vic" {
+		t.Fatalf("wanted Vic to be host, got %s", l.Host)
+	}
+
+	l.SetReady("Gabrielle", true)
+	g, err := l.Start("Vic")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if g.ID != l.ID || g.Rounds != 13 || len(g.Players) != 2 || g.Players[0].ID != "Vic" {
+		t.Fatalf("game was not created from the lobby: %s", g.Board())
+	}
+
+	if err := l.Join("Joxer"); err != ErrLobbyClosed {
+		t.Fatalf("wanted %v, got %v", ErrLobbyClosed, err)
+	}
+}
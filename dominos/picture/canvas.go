@@ -0,0 +1,89 @@
+package picture
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// samples is how many points per side of a pixel are looked at to smooth
+// the edges of shapes.
+const samples = 3
+
+// shader returns the color of a shape at a point, or false if the point is
+// outside of it.
+type shader func(x, y float64) (color.RGBA, bool)
+
+// canvas is an image that shapes are painted on, each over what was painted
+// before.
+type canvas struct {
+	*image.RGBA
+}
+
+func newCanvas(w, h int, bg color.RGBA) *canvas {
+	c := &canvas{RGBA: image.NewRGBA(image.Rect(0, 0, w, h))}
+	for i := 0; i < len(c.Pix); i += 4 {
+		c.Pix[i], c.Pix[i+1], c.Pix[i+2], c.Pix[i+3] = bg.R, bg.G, bg.B, bg.A
+	}
+
+	return c
+}
+
+// fill paints a shape over every pixel of the canvas within r of (x, y),
+// which must cover all of it.
+func (c *canvas) fill(x, y, r float64, s shader) {
+	b := image.Rect(int(x-r)-1, int(y-r)-1, int(x+r)+2, int(y+r)+2).Intersect(c.Bounds())
+
+	for py := b.Min.Y; py < b.Max.Y; py++ {
+		for px := b.Min.X; px < b.Max.X; px++ {
+			var sr, sg, sb, sa float64
+			for i := 0; i < samples; i++ {
+				for j := 0; j < samples; j++ {
+					col, ok := s(float64(px)+(float64(i)+0.5)/samples, float64(py)+(float64(j)+0.5)/samples)
+					if !ok {
+						continue
+					}
+
+					a := float64(col.A) / 255
+					sr += float64(col.R) * a
+					sg += float64(col.G) * a
+					sb += float64(col.B) * a
+					sa += a
+				}
+			}
+
+			if sa == 0 {
+				continue
+			}
+
+			n := float64(samples * samples)
+			c.blend(px, py, sr/n, sg/n, sb/n, sa/n)
+		}
+	}
+}
+
+// blend paints a premultiplied color over a pixel.
+func (c *canvas) blend(x, y int, r, g, b, a float64) {
+	i := c.PixOffset(x, y)
+	p := c.Pix[i : i+4 : i+4]
+
+	p[0] = uint8(math.Round(r + float64(p[0])*(1-a)))
+	p[1] = uint8(math.Round(g + float64(p[1])*(1-a)))
+	p[2] = uint8(math.Round(b + float64(p[2])*(1-a)))
+	p[3] = uint8(math.Round(255*a + float64(p[3])*(1-a)))
+}
+
+// disc paints a circle.
+func (c *canvas) disc(x, y, r float64, col color.RGBA) {
+	c.fill(x, y, r, func(px, py float64) (color.RGBA, bool) {
+		return col, (px-x)*(px-x)+(py-y)*(py-y) <= r*r
+	})
+}
+
+// ring paints the outline of a circle, width wide.
+func (c *canvas) ring(x, y, r, width float64, col color.RGBA) {
+	c.fill(x, y, r, func(px, py float64) (color.RGBA, bool) {
+		d := math.Hypot(px-x, py-y)
+		return col, d <= r && d >= r-width
+	})
+}
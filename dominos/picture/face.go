@@ -0,0 +1,168 @@
+package picture
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+
+	"github.com/cetacean/magiism/dominos"
+)
+
+// MaxPips is the most pips a face has in the biggest set that is dealt.
+const MaxPips = dominos.MaxSet
+
+// Sizes of a face drawn on its own, in pixels. Discord shows custom emoji
+// at most 128 pixels wide.
+const (
+	EmojiSize = 128
+	FullSize  = 512
+)
+
+// pipColors give every number its own color, the way real sets are painted,
+// so that matching ends can be spotted at a glance.
+var pipColors = [MaxPips + 1]color.RGBA{
+	{A: 0xff},
+	{R: 0x2f, G: 0x9b, B: 0xd6, A: 0xff}, // Light blue.
+	{R: 0x2e, G: 0x9e, B: 0x45, A: 0xff}, // Green.
+	{R: 0xd6, G: 0x2e, B: 0x2e, A: 0xff}, // Red.
+	{R: 0x8a, G: 0x55, B: 0x2b, A: 0xff}, // Brown.
+	{R: 0x1f, G: 0x3d, B: 0xa8, A: 0xff}, // Dark blue.
+	{R: 0xe0, G: 0xa8, B: 0x10, A: 0xff}, // Yellow.
+	{R: 0x9b, G: 0x3b, B: 0xc4, A: 0xff}, // Purple.
+	{R: 0x15, G: 0x5e, B: 0x33, A: 0xff}, // Dark green.
+	{R: 0x1c, G: 0x1c, B: 0x1c, A: 0xff}, // Black.
+	{R: 0xf2, G: 0x7a, B: 0x1a, A: 0xff}, // Orange.
+	{R: 0x80, G: 0x14, B: 0x2a, A: 0xff}, // Maroon.
+	{R: 0x70, G: 0x70, B: 0x70, A: 0xff}, // Gray.
+	{R: 0xe8, G: 0x5d, B: 0xa8, A: 0xff}, // Pink.
+	{R: 0x12, G: 0x8c, B: 0x8c, A: 0xff}, // Teal.
+	{R: 0x7d, G: 0x80, B: 0x10, A: 0xff}, // Olive.
+	{R: 0x14, G: 0x1f, B: 0x5c, A: 0xff}, // Navy.
+	{R: 0x7c, G: 0xc2, B: 0x1f, A: 0xff}, // Lime.
+	{R: 0x4b, G: 0x2a, B: 0x80, A: 0xff}, // Indigo.
+}
+
+// pipColor returns the color of the pips of a face showing n.
+func pipColor(n int) color.RGBA {
+	if n < 0 || n > MaxPips {
+		return pipColors[0]
+	}
+	return pipColors[n]
+}
+
+// point is a spot on a face, from (0, 0) at its top left to (1, 1) at its
+// bottom right.
+type point struct {
+	X, Y float64
+}
+
+// classic are the faces everyone knows from dice, up to nine on a three by
+// three grid.
+var classic = [][]point{
+	{},
+	{{0.5, 0.5}},
+	{{0.2, 0.2}, {0.8, 0.8}},
+	{{0.2, 0.2}, {0.5, 0.5}, {0.8, 0.8}},
+	{{0.2, 0.2}, {0.8, 0.2}, {0.2, 0.8}, {0.8, 0.8}},
+	{{0.2, 0.2}, {0.8, 0.2}, {0.5, 0.5}, {0.2, 0.8}, {0.8, 0.8}},
+	{{0.2, 0.2}, {0.8, 0.2}, {0.2, 0.5}, {0.8, 0.5}, {0.2, 0.8}, {0.8, 0.8}},
+	{{0.2, 0.2}, {0.8, 0.2}, {0.2, 0.5}, {0.5, 0.5}, {0.8, 0.5}, {0.2, 0.8}, {0.8, 0.8}},
+	{{0.2, 0.2}, {0.5, 0.2}, {0.8, 0.2}, {0.2, 0.5}, {0.8, 0.5}, {0.2, 0.8}, {0.5, 0.8}, {0.8, 0.8}},
+	{{0.2, 0.2}, {0.5, 0.2}, {0.8, 0.2}, {0.2, 0.5}, {0.5, 0.5}, {0.8, 0.5}, {0.2, 0.8}, {0.5, 0.8}, {0.8, 0.8}},
+}
+
+// pips returns where the pips of a face showing n go, and how big they are
+// relative to the face. Past nine they are laid out in rows of three, the way
+// bigger sets are made, with what is left over centered on the last row.
+func pips(n int) ([]point, float64) {
+	if n < len(classic) {
+		return classic[n], 0.1
+	}
+
+	rows := (n + 2) / 3
+	step := 0.7 / float64(rows-1)
+
+	var ps []point
+	for i := 0; i < n; i++ {
+		row, col := i/3, i%3
+
+		x := 0.2 + 0.3*float64(col)
+		if row == rows-1 {
+			switch n % 3 {
+			case 1:
+				x = 0.5
+			case 2:
+				x = 0.2 + 0.6*float64(col)
+			}
+		}
+
+		ps = append(ps, point{X: x, Y: 0.15 + step*float64(row)})
+	}
+
+	return ps, math.Min(0.1, step*0.4)
+}
+
+// face returns a shader for the pips of a face showing n, taking points on
+// the face from (0, 0) to (1, 1).
+func face(n int) shader {
+	ps, r := pips(n)
+	col := pipColor(n)
+
+	return func(x, y float64) (color.RGBA, bool) {
+		for _, p := range ps {
+			dx, dy := x-(0.08+p.X*0.84), y-(0.08+p.Y*0.84)
+			if dx*dx+dy*dy <= r*r {
+				return col, true
+			}
+		}
+
+		return color.RGBA{}, false
+	}
+}
+
+// rounded returns true if (x, y) is inside a rectangle centered on zero, w
+// wide and h high, with corners rounded by r.
+func rounded(x, y, w, h, r float64) bool {
+	x, y = math.Abs(x)-(w/2-r), math.Abs(y)-(h/2-r)
+	if x <= 0 || y <= 0 {
+		return x <= r && y <= r
+	}
+	return x*x+y*y <= r*r
+}
+
+// Face draws a single face showing n, size pixels square, on a transparent
+// background. These are the pieces emoji are made from.
+func Face(n, size int) image.Image {
+	c := newCanvas(size, size, color.RGBA{})
+	s := float64(size)
+	pip := face(n)
+
+	c.fill(s/2, s/2, s, func(x, y float64) (color.RGBA, bool) {
+		x, y = x/s-0.5, y/s-0.5
+		switch {
+		case !rounded(x, y, 0.96, 0.96, 0.12):
+			return color.RGBA{}, false
+		case !rounded(x, y, 0.88, 0.88, 0.08):
+			return edgeColor, true
+		}
+
+		if col, ok := pip(x+0.5, y+0.5); ok {
+			return col, true
+		}
+		return tileColor, true
+	})
+
+	return c.RGBA
+}
+
+// FacePNG returns Face as a PNG.
+func FacePNG(n, size int) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, Face(n, size)); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
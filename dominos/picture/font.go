@@ -0,0 +1,40 @@
+package picture
+
+import "image/color"
+
+// glyphs are the digits, three dots wide and five high, a row per string.
+var glyphs = map[rune][5]string{
+	'0': {"###", "#.#", "#.#", "#.#", "###"},
+	'1': {".#.", "##.", ".#.", ".#.", "###"},
+	'2': {"###", "..#", "###", "#..", "###"},
+	'3': {"###", "..#", ".##", "..#", "###"},
+	'4': {"#.#", "#.#", "###", "..#", "..#"},
+	'5': {"###", "#..", "###", "..#", "###"},
+	'6': {"###", "#..", "###", "#.#", "###"},
+	'7': {"###", "..#", ".#.", ".#.", ".#."},
+	'8': {"###", "#.#", "###", "#.#", "###"},
+	'9': {"###", "#.#", "###", "..#", "###"},
+}
+
+// text writes a string of digits centered on (x, y), height pixels high.
+func (c *canvas) text(x, y, height float64, s string, col color.RGBA) {
+	dot := height / 5
+	width := dot * float64(4*len(s)-1)
+	left, top := x-width/2, y-height/2
+
+	for i, r := range s {
+		g, ok := glyphs[r]
+		if !ok {
+			continue
+		}
+
+		gx := left + dot*float64(4*i)
+		c.fill(gx+dot*1.5, y, height, func(px, py float64) (color.RGBA, bool) {
+			col3, row := int((px-gx)/dot), int((py-top)/dot)
+			if px < gx || py < top || col3 > 2 || row > 4 {
+				return col, false
+			}
+			return col, g[row][col3] == '#'
+		})
+	}
+}
@@ -0,0 +1,170 @@
+// Package picture draws the board of a game of Mexican Train as an image,
+// for frontends where a long train is hard to follow as text. The center
+// double sits in the middle and every path is a spoke out from it, with its
+// tiles turned the way they were played.
+package picture
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"strconv"
+
+	"github.com/cetacean/magiism/dominos"
+)
+
+// Sizes of a board picture, in pixels.
+const (
+	// DefaultUnit is how long half a tile is, unless that makes the picture
+	// bigger than MaxSize. It never gets smaller than MinUnit, so that pips
+	// can still be counted on a board with a very long train.
+	DefaultUnit = 24
+	MinUnit     = 8
+	MaxSize     = 2048
+)
+
+// Colors of the board, besides the tiles.
+var (
+	tableColor    = color.RGBA{R: 0x23, G: 0x5a, B: 0x3a, A: 0xff}
+	labelColor    = color.RGBA{R: 0xdd, G: 0xdd, B: 0xdd, A: 0xff}
+	mexicanColor  = color.RGBA{R: 0xc8, G: 0x3c, B: 0x32, A: 0xff}
+	desertedColor = color.RGBA{R: 0x70, G: 0x70, B: 0x70, A: 0xff}
+	trainColor    = color.RGBA{R: 0xf2, G: 0x8c, B: 0x28, A: 0xff}
+	turnColor     = openColor
+	digitColor    = color.RGBA{R: 0x20, G: 0x20, B: 0x20, A: 0xff}
+)
+
+// Distances along a spoke, in halves of a tile.
+const (
+	gap     = 0.15 // Between tiles.
+	label   = 0.45 // Radius of the path's number.
+	marker  = 0.3  // Radius of the marker on a path with a train on it.
+	hub     = 1.3  // How much bigger the center double is than other tiles.
+	minHole = 2.6  // Least distance from the center to the start of a spoke.
+	margin  = 1
+)
+
+// length is how far along its spoke a tile reaches. Doubles are laid across.
+func length(d dominos.Domino) float64 {
+	if d.IsDouble() {
+		return 1
+	}
+	return 2
+}
+
+// hole is where the spokes of a board with n paths start, far enough out
+// that a double laid across one doesn't run into the next.
+func hole(n int) float64 {
+	return math.Max(minHole, float64(n)*2.2/(2*math.Pi))
+}
+
+// reach is how far from the center a path goes, in halves of a tile.
+func reach(p *dominos.Path, n int) float64 {
+	r := hole(n) + 2*label + gap
+	for _, e := range p.Elements {
+		r += length(e.Domino) + gap
+	}
+
+	return r + 2*marker
+}
+
+// Board draws the board of a game.
+func Board(g *dominos.Game) image.Image {
+	n := len(g.Trains)
+
+	far := hole(n) + hub
+	for _, p := range g.Trains {
+		far = math.Max(far, reach(p, n))
+	}
+	far += margin
+
+	u := math.Min(DefaultUnit, math.Floor(MaxSize/(2*far)))
+	u = math.Max(u, MinUnit)
+
+	size := int(math.Ceil(2 * far * u))
+	c := newCanvas(size, size, tableColor)
+	mid := float64(size) / 2
+
+	var active *dominos.Path
+	if g.ActivePlayer >= 0 && g.ActivePlayer < len(g.Players) {
+		active = g.Players[g.ActivePlayer].Path
+	}
+
+	for i, p := range g.Trains {
+		// Clockwise from the top.
+		angle := -math.Pi/2 + 2*math.Pi*float64(i)/float64(n)
+		dx, dy := math.Cos(angle), math.Sin(angle)
+		at := func(r float64) (float64, float64) {
+			return mid + dx*r*u, mid + dy*r*u
+		}
+
+		r := hole(n) + label
+		x, y := at(r)
+
+		col := labelColor
+		switch {
+		case p.MexicanTrain:
+			col = mexicanColor
+		case p.Deserted:
+			col = desertedColor
+		}
+		if p == active {
+			c.disc(x, y, (label+0.12)*u, turnColor)
+		}
+		c.disc(x, y, label*u, col)
+		c.text(x, y, label*u, strconv.Itoa(i), digitColor)
+
+		r += label + gap
+		for j, e := range p.Elements {
+			l := length(e.Domino)
+			x, y := at(r + l/2)
+
+			t := tile{
+				X: x, Y: y, DX: dx, DY: dy, Size: u,
+				First: e.Left, Second: e.Right,
+				Open: p.UnresolvedDouble && j == len(p.Elements)-1,
+			}
+			if e.Flipped {
+				t.First, t.Second = e.Right, e.Left
+			}
+			if e.IsDouble() {
+				t.DX, t.DY = -dy, dx
+			}
+
+			t.paint(c)
+			r += l + gap
+		}
+
+		if p.Train && !p.MexicanTrain {
+			x, y := at(r + marker)
+			c.disc(x, y, marker*u, trainColor)
+			c.ring(x, y, marker*u, u*0.08, edgeColor)
+		}
+	}
+
+	center := tile{
+		X: mid, Y: mid, DX: 1, Size: u * hub,
+		First: g.Center.Left, Second: g.Center.Right,
+	}
+	center.paint(c)
+
+	return c.RGBA
+}
+
+// Encode writes a picture of the board of a game as a PNG.
+func Encode(w io.Writer, g *dominos.Game) error {
+	return png.Encode(w, Board(g))
+}
+
+// PNG returns a picture of the board of a game as a PNG.
+func PNG(g *dominos.Game) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := Encode(&buf, g); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,120 @@
+package picture
+
+import (
+	"bytes"
+	"fmt"
+	"image/png"
+	"testing"
+
+	"github.com/cetacean/magiism/dominos"
+)
+
+func TestPips(t *testing.T) {
+	for n := 0; n <= 18; n++ {
+		ps, r := pips(n)
+		if len(ps) != n {
+			t.Errorf("%d: got %d pips", n, len(ps))
+		}
+
+		for i, p := range ps {
+			if p.X-r < 0 || p.X+r > 1 || p.Y-r < 0 || p.Y+r > 1 {
+				t.Errorf("%d: pip %v is off the face", n, p)
+			}
+
+			for _, q := range ps[i+1:] {
+				if dx, dy := p.X-q.X, p.Y-q.Y; dx*dx+dy*dy < 4*r*r {
+					t.Errorf("%d: pips %v and %v overlap", n, p, q)
+				}
+			}
+		}
+	}
+}
+
+const testBoard = `center [12||12]
+turn Vic
+    Xena >> [12|1] [1|4] [4|18] [18|9] [9||9] [9|15] *
+     Vic >> [12|3]
+     Ann >> [12|8] (left)
+       M >> [12|4] [4||4] <!>
+`
+
+func TestBoard(t *testing.T) {
+	g, err := dominos.ParseBoard(testBoard)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := PNG(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	img, err := png.Decode(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	size := img.Bounds().Size()
+	if size.X != size.Y || size.X > MaxSize {
+		t.Fatalf("got a %v picture", size)
+	}
+
+	// The middle of the picture is the center double's divider.
+	if r, _, _, _ := img.At(size.X/2, size.Y/2).RGBA(); r>>8 > 0x80 {
+		t.Errorf("got %v in the middle", img.At(size.X/2, size.Y/2))
+	}
+	if got := img.At(1, 1); got != tableColor {
+		t.Errorf("got %v in the corner", got)
+	}
+}
+
+func TestBoardFits(t *testing.T) {
+	// Every tile of a double-18 set one or two apart, in a single train.
+	long := "center [18||18]\n    Xena >>"
+	end := 18
+	for _, step := range []int{1, 2} {
+		for i := 0; i < 19; i++ {
+			next := (end + step) % 19
+			long += fmt.Sprintf(" [%d|%d]", end, next)
+			end = next
+		}
+	}
+	long += "\n       M >>\n"
+
+	g, err := dominos.ParseBoard(long)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if size := Board(g).Bounds().Size(); size.X > MaxSize || size.Y > MaxSize {
+		t.Errorf("got a %v picture", size)
+	}
+}
+
+func TestFace(t *testing.T) {
+	img := Face(1, EmojiSize)
+	if size := img.Bounds().Size(); size.X != EmojiSize || size.Y != EmojiSize {
+		t.Fatalf("got a %v face", size)
+	}
+
+	if _, _, _, a := img.At(0, 0).RGBA(); a != 0 {
+		t.Errorf("the corner isn't transparent")
+	}
+	if got := img.At(EmojiSize/2, EmojiSize/2); got != pipColors[1] {
+		t.Errorf("got %v in the middle of a one", got)
+	}
+	if got := Face(0, EmojiSize).At(EmojiSize/2, EmojiSize/2); got != tileColor {
+		t.Errorf("got %v in the middle of a blank", got)
+	}
+}
+
+func TestPipColors(t *testing.T) {
+	for i, a := range pipColors {
+		for j, b := range pipColors[i+1:] {
+			dr, dg, db := int(a.R)-int(b.R), int(a.G)-int(b.G), int(a.B)-int(b.B)
+			if dr*dr+dg*dg+db*db < 40*40 {
+				t.Errorf("%d and %d look alike", i, i+1+j)
+			}
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package picture
+
+import (
+	"image/color"
+	"math"
+)
+
+// Colors of a tile.
+var (
+	tileColor    = color.RGBA{R: 0xf7, G: 0xf3, B: 0xe6, A: 0xff}
+	edgeColor    = color.RGBA{R: 0x3a, G: 0x3a, B: 0x3a, A: 0xff}
+	openColor    = color.RGBA{R: 0xff, G: 0xc8, B: 0x2e, A: 0xff}
+	shadowColor  = color.RGBA{A: 0x50}
+	shadowOffset = 0.06
+)
+
+// tile is a tile placed on the board.
+type tile struct {
+	X, Y   float64 // Center, in pixels.
+	DX, DY float64 // Unit vector along the tile, from First to Second.
+	Size   float64 // Length of a half, the tile is twice as long.
+
+	First, Second int
+	Open          bool // A double waiting to be covered.
+}
+
+// paint draws the tile.
+func (t tile) paint(c *canvas) {
+	u := t.Size
+	reach := u * 1.2
+
+	// Along and across the tile from its center, in halves.
+	local := func(x, y float64) (float64, float64) {
+		x, y = x-t.X, y-t.Y
+		return (x*t.DX + y*t.DY) / u, (-x*t.DY + y*t.DX) / u
+	}
+
+	inside := func(s, w, margin float64) bool {
+		return rounded(s, w, 2-2*margin, 1-2*margin, 0.12)
+	}
+
+	c.fill(t.X+u*shadowOffset, t.Y+u*shadowOffset, reach, func(x, y float64) (color.RGBA, bool) {
+		s, w := local(x-u*shadowOffset, y-u*shadowOffset)
+		return shadowColor, inside(s, w, 0)
+	})
+
+	if t.Open {
+		c.fill(t.X, t.Y, reach+u*0.2, func(x, y float64) (color.RGBA, bool) {
+			s, w := local(x, y)
+			return openColor, inside(s*0.9, w*0.8, 0)
+		})
+	}
+
+	first, second := face(t.First), face(t.Second)
+
+	c.fill(t.X, t.Y, reach, func(x, y float64) (color.RGBA, bool) {
+		s, w := local(x, y)
+		if !inside(s, w, 0) {
+			return color.RGBA{}, false
+		}
+		if !inside(s, w, 0.04) || math.Abs(s) < 0.025 {
+			return edgeColor, true
+		}
+
+		// Where on its face the point is. A face is upright when the tile
+		// points right.
+		pip, fx := first, s+1
+		if s > 0 {
+			pip, fx = second, s
+		}
+
+		if col, ok := pip(fx, w+0.5); ok {
+			return col, true
+		}
+		return tileColor, true
+	})
+}
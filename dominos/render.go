@@ -0,0 +1,116 @@
+package dominos
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Renderer draws tiles as text for one kind of frontend. Everything else
+// that is shown of a game, such as paths and hands, is built out of tiles by
+// RenderElement, RenderPath and RenderHand, so a frontend only has to pick
+// a Renderer.
+type Renderer interface {
+	// Tile draws a tile, left side first.
+	Tile(d Domino) string
+}
+
+// Renderers are the styles that don't depend on where they are shown, by
+// name. Emojis needs a Discord guild's own emoji, so it isn't one of them.
+var Renderers = map[string]Renderer{
+	"text":    Text{},
+	"unicode": Unicode{},
+	"ansi":    ANSI{},
+}
+
+// Text draws tiles in plain ASCII, such as [6|4], with doubles as [6||6].
+type Text struct{}
+
+// Tile implements Renderer.
+func (Text) Tile(d Domino) string {
+	if d.IsDouble() {
+		return fmt.Sprintf("[%d||%d]", d.Left, d.Right)
+	}
+	return fmt.Sprintf("[%d|%d]", d.Left, d.Right)
+}
+
+// Unicode draws tiles as the Unicode domino tiles, up to double-six, and as
+// Text beyond that.
+type Unicode struct{}
+
+// Tile implements Renderer.
+func (Unicode) Tile(d Domino) string {
+	if s, ok := d.Unicode(); ok {
+		return s
+	}
+	return Text{}.Tile(d)
+}
+
+// ansiColors are the xterm colors of every number of pips, close to the
+// colors real sets paint them in.
+var ansiColors = []string{
+	"", "38;5;39", "38;5;34", "38;5;160", "38;5;94", "38;5;20", "38;5;178",
+	"38;5;134", "38;5;22", "1", "38;5;208", "38;5;88", "38;5;244", "38;5;205",
+	"38;5;30", "38;5;100", "38;5;18", "38;5;112", "38;5;55",
+}
+
+// ANSI draws tiles like Text, with each number in its own color, for
+// terminals.
+type ANSI struct{}
+
+// Tile implements Renderer.
+func (ANSI) Tile(d Domino) string {
+	side := func(n int) string {
+		if n < 0 || n >= len(ansiColors) || ansiColors[n] == "" {
+			return strconv.Itoa(n)
+		}
+		return "\x1b[" + ansiColors[n] + "m" + strconv.Itoa(n) + "\x1b[0m"
+	}
+
+	if d.IsDouble() {
+		return "[" + side(d.Left) + "||" + side(d.Right) + "]"
+	}
+	return "[" + side(d.Left) + "|" + side(d.Right) + "]"
+}
+
+// RenderElement draws a tile on a path, with the side touching the tile
+// before it first.
+func RenderElement(r Renderer, e *Element) string {
+	if e.Flipped {
+		return r.Tile(Domino{Left: e.Right, Right: e.Left})
+	}
+	return r.Tile(e.Domino)
+}
+
+// RenderPath draws a path the way Path.Display does: its owner, or M for
+// the Mexican train, its numbered tiles from the center outwards, and a *
+// if it has a train on it or <!> if it ends in an unresolved double.
+func RenderPath(r Renderer, p *Path) string {
+	owner := p.Player
+	if p.MexicanTrain {
+		owner = "M"
+	}
+
+	result := fmt.Sprintf("%8s >>", owner)
+	for i, e := range p.Elements {
+		result += fmt.Sprintf(" %d:%s", i, RenderElement(r, e))
+	}
+	if p.Train {
+		result += " *"
+	}
+	if p.UnresolvedDouble {
+		result += " <!>"
+	}
+
+	return result
+}
+
+// RenderHand draws a hand, each tile numbered by its index.
+func RenderHand(r Renderer, hand []Domino) string {
+	var tiles []string
+	for i, d := range hand {
+		tiles = append(tiles, fmt.Sprintf("%d:%s", i, r.Tile(d)))
+	}
+
+	return strings.Join(tiles, " ")
+}
@@ -0,0 +1,66 @@
+package dominos
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestRenderers(t *testing.T) {
+	ansi := regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+	for n := 0; n <= 18; n++ {
+		tiles := []Domino{{Left: n, Right: 3}, {Left: n, Right: n}}
+		for _, d := range tiles {
+			text := Text{}.Tile(d)
+			colored := ANSI{}.Tile(d)
+			if got := ansi.ReplaceAllString(colored, ""); got != text {
+				t.Errorf("ansi %s: got %q without colors", text, got)
+			}
+
+			_, ok := d.Unicode()
+			uni := Unicode{}.Tile(d)
+			if (uni == text) == ok {
+				t.Errorf("unicode %s: got %q", text, uni)
+			}
+		}
+	}
+}
+
+func TestRenderPath(t *testing.T) {
+	p := &Path{
+		Player: "Xena",
+		Train:  true,
+		Elements: []*Element{
+			{Domino: Domino{Left: 6, Right: 1}},
+			{Domino: Domino{Left: 4, Right: 1}, Flipped: true},
+		},
+	}
+
+	if got, want := RenderPath(Text{}, p), "    Xena >> 0:[6|1] 1:[1|4] *"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got, want := RenderPath(Unicode{}, p), "    Xena >> 0:\U0001F05C 1:\U0001F03C *"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	m := &Path{MexicanTrain: true, Train: true, UnresolvedDouble: true,
+		Elements: []*Element{{Domino: Domino{Left: 6, Right: 6}}}}
+	if got, want := RenderPath(Text{}, m), "       M >> 0:[6||6] * <!>"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestRenderHand(t *testing.T) {
+	hand := []Domino{{Left: 5, Right: 5}, {Left: 2, Right: 0}}
+	if got, want := RenderHand(Text{}, hand), "0:[5||5] 1:[2|0]"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	p := &Player{Hand: hand}
+	if got, want := p.Display(), "YOUR HAND: 0:[5||5] 1:[2|0]"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got, want := (&Player{}).Display(), "YOUR HAND:"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,280 @@
+package dominos
+
+// maxTrainSteps bounds how long LongestTrain searches, so that a huge hand
+// can't stall a game.
+const maxTrainSteps = 1 << 18
+
+// Metric is what LongestTrain makes the most of.
+type Metric int
+
+// Possible metrics for LongestTrain.
+const (
+	// MostTiles plays as many tiles as possible, then as many pips.
+	MostTiles Metric = iota
+
+	// MostPips gets rid of as many pips as possible, then as many tiles.
+	MostPips
+)
+
+// LongestTrain finds the best train that can be built out of hand starting
+// from the pip start, such as from the center at the start of a round or
+// from the end of the player's own path. It returns the tiles of the train
+// in the order they are played, each turned so that its Left side matches
+// the tile before it, and the tiles left over in the order they were in the
+// hand.
+//
+// Trains never end in a double, as a double has to be satisfied. Any double
+// the train passes through is always worth playing, so only the other tiles
+// are searched, as edges between pips. Searching for the longest trail is
+// still exponential in the worst case, but whatever can't beat the best
+// train found so far is cut off early, which keeps hands dealt from a
+// double-15 or double-18 set quick to solve. Hands so big that this still
+// takes too long, such as 30 tiles from a double-12 set, get the best train
+// found in the time allowed.
+func LongestTrain(hand []Domino, start int, m Metric) (train, rest []Domino) {
+	s := newTrainSearch(hand, start, m)
+	s.topT, s.topP = s.bound(start)
+	s.walk(start)
+
+	used := make([]bool, len(hand))
+	for _, i := range s.best {
+		used[i] = true
+	}
+
+	end := start
+	for _, i := range s.best {
+		d := hand[i]
+		if d.Left != end {
+			d.Left, d.Right = d.Right, d.Left
+		}
+		train = append(train, d)
+		end = d.Right
+	}
+
+	for i, d := range hand {
+		if !used[i] {
+			rest = append(rest, d)
+		}
+	}
+
+	return train, rest
+}
+
+// trainSearch is the state of a LongestTrain search.
+type trainSearch struct {
+	hand   []Domino
+	metric Metric
+
+	edges   [][]int // Indexes of the tiles that aren't doubles, by pip.
+	doubles []int   // Index of the double of each pip, or -1.
+	used    []bool
+
+	cur, best    []int
+	tiles, pips  int
+	bestT, bestP int
+	maxT         int // How many tiles a train using the whole hand has.
+
+	// The same tiles played in a different order to end on the same pip
+	// score the same and can go on in the same ways, so each combination is
+	// only searched once. This only works for hands of up to 64 tiles.
+	mask uint64
+	done map[trainState]bool
+
+	// The most the whole search could find. Once a train this good turns
+	// up, there is nothing left to look for.
+	topT, topP int
+
+	// Scratch space for bound.
+	seen, odd []bool
+	stack     []int
+
+	steps int
+}
+
+func newTrainSearch(hand []Domino, start int, m Metric) *trainSearch {
+	top := start
+	for _, d := range hand {
+		if d.Left > top {
+			top = d.Left
+		}
+		if d.Right > top {
+			top = d.Right
+		}
+	}
+
+	s := &trainSearch{
+		hand:    hand,
+		metric:  m,
+		edges:   make([][]int, top+1),
+		doubles: make([]int, top+1),
+		used:    make([]bool, len(hand)),
+		seen:    make([]bool, top+1),
+		odd:     make([]bool, top+1),
+	}
+
+	if len(hand) <= 64 {
+		s.done = map[trainState]bool{}
+	}
+
+	for i := range s.doubles {
+		s.doubles[i] = -1
+	}
+
+	s.maxT = len(hand)
+	for i, d := range hand {
+		if d.IsDouble() {
+			s.doubles[d.Left] = i
+			continue
+		}
+
+		s.edges[d.Left] = append(s.edges[d.Left], i)
+		s.edges[d.Right] = append(s.edges[d.Right], i)
+	}
+
+	return s
+}
+
+// trainState is a set of tiles played and the pip they end on.
+type trainState struct {
+	mask uint64
+	end  int
+}
+
+// better returns true if a train worth tiles and pips beats one worth bt and
+// bp.
+func (s *trainSearch) better(tiles, pips, bt, bp int) bool {
+	if s.metric == MostPips {
+		tiles, pips, bt, bp = pips, tiles, bp, bt
+	}
+
+	return tiles > bt || (tiles == bt && pips > bp)
+}
+
+// walk extends the current train from the pip end in every way that could
+// still beat the best train.
+func (s *trainSearch) walk(end int) {
+	if s.better(s.tiles, s.pips, s.bestT, s.bestP) {
+		s.best = append(s.best[:0], s.cur...)
+		s.bestT, s.bestP = s.tiles, s.pips
+	}
+
+	s.steps++
+	if s.steps > maxTrainSteps || s.bestT == s.maxT || (s.bestT == s.topT && s.bestP == s.topP) {
+		return
+	}
+
+	if s.done != nil {
+		st := trainState{mask: s.mask, end: end}
+		if s.done[st] {
+			return
+		}
+		s.done[st] = true
+	}
+
+	bt, bp := s.bound(end)
+	if !s.better(s.tiles+bt, s.pips+bp, s.bestT, s.bestP) {
+		return
+	}
+
+	// Leaving a pip is the time to play its double.
+	double := s.doubles[end]
+	if double >= 0 && s.used[double] {
+		double = -1
+	}
+
+	for _, i := range s.edges[end] {
+		if s.used[i] {
+			continue
+		}
+
+		if double >= 0 {
+			s.push(double)
+		}
+		s.push(i)
+
+		d := s.hand[i]
+		if d.Left == end {
+			s.walk(d.Right)
+		} else {
+			s.walk(d.Left)
+		}
+
+		s.pop()
+		if double >= 0 {
+			s.pop()
+		}
+	}
+}
+
+func (s *trainSearch) push(i int) {
+	s.used[i] = true
+	s.mask ^= 1 << uint(i)
+	s.cur = append(s.cur, i)
+	s.tiles++
+	s.pips += s.hand[i].Value()
+}
+
+func (s *trainSearch) pop() {
+	i := s.cur[len(s.cur)-1]
+	s.used[i] = false
+	s.mask ^= 1 << uint(i)
+	s.cur = s.cur[:len(s.cur)-1]
+	s.tiles--
+	s.pips -= s.hand[i].Value()
+}
+
+// bound returns at most how many tiles and pips could still be added to a
+// train ending at the pip end. Only unused tiles connected to end can be
+// added, and a trail has to leave out at least one tile for every two pips
+// other than its ends that an odd number of those tiles touch.
+func (s *trainSearch) bound(end int) (tiles, pips int) {
+	for i := range s.seen {
+		s.seen[i] = false
+		s.odd[i] = false
+	}
+
+	s.stack = append(s.stack[:0], end)
+	s.seen[end] = true
+
+	// Every tile is found from both of its ends, so these count double.
+	edges, edgePips, odd := 0, 0, 0
+	for len(s.stack) > 0 {
+		v := s.stack[len(s.stack)-1]
+		s.stack = s.stack[:len(s.stack)-1]
+
+		if d := s.doubles[v]; d >= 0 && !s.used[d] {
+			tiles++
+			pips += s.hand[d].Value()
+		}
+
+		for _, i := range s.edges[v] {
+			if s.used[i] {
+				continue
+			}
+
+			s.odd[v] = !s.odd[v]
+			edges++
+			edgePips += s.hand[i].Value()
+
+			w := s.hand[i].Left
+			if w == v {
+				w = s.hand[i].Right
+			}
+			if !s.seen[w] {
+				s.seen[w] = true
+				s.stack = append(s.stack, w)
+			}
+		}
+
+		if s.odd[v] {
+			odd++
+		}
+	}
+
+	edges /= 2
+	if odd > 2 {
+		edges -= (odd - 2) / 2
+	}
+
+	return tiles + edges, pips + edgePips/2
+}
@@ -0,0 +1,234 @@
+package dominos
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestLongestTrain(t *testing.T) {
+	cases := []struct {
+		name  string
+		hand  []Domino
+		start int
+		m     Metric
+		train []Domino
+		rest  []Domino
+	}{
+		{
+			name:  "empty hand",
+			start: 12,
+		},
+		{
+			name:  "nothing fits",
+			hand:  []Domino{{Left: 1, Right: 2}, {Left: 3, Right: 3}},
+			start: 12,
+			rest:  []Domino{{Left: 1, Right: 2}, {Left: 3, Right: 3}},
+		},
+		{
+			name:  "lonely double",
+			hand:  []Domino{{Left: 12, Right: 12}},
+			start: 12,
+			rest:  []Domino{{Left: 12, Right: 12}},
+		},
+		{
+			name: "doubles in the middle",
+			hand: []Domino{
+				{Left: 5, Right: 1},
+				{Left: 12, Right: 3},
+				{Left: 3, Right: 3},
+				{Left: 3, Right: 5},
+				{Left: 1, Right: 1},
+			},
+			start: 12,
+			train: []Domino{
+				{Left: 12, Right: 3},
+				{Left: 3, Right: 3},
+				{Left: 3, Right: 5},
+				{Left: 5, Right: 1},
+			},
+			rest: []Domino{{Left: 1, Right: 1}},
+		},
+		{
+			name: "double at the start",
+			hand: []Domino{
+				{Left: 4, Right: 9},
+				{Left: 9, Right: 9},
+			},
+			start: 9,
+			train: []Domino{
+				{Left: 9, Right: 9},
+				{Left: 9, Right: 4},
+			},
+		},
+		{
+			name: "most tiles",
+			hand: []Domino{
+				{Left: 1, Right: 12},
+				{Left: 0, Right: 1},
+				{Left: 0, Right: 2},
+				{Left: 12, Right: 11},
+			},
+			start: 12,
+			train: []Domino{
+				{Left: 12, Right: 1},
+				{Left: 1, Right: 0},
+				{Left: 0, Right: 2},
+			},
+			rest: []Domino{{Left: 12, Right: 11}},
+		},
+		{
+			name: "most pips",
+			hand: []Domino{
+				{Left: 1, Right: 12},
+				{Left: 0, Right: 1},
+				{Left: 0, Right: 2},
+				{Left: 12, Right: 11},
+			},
+			start: 12,
+			m:     MostPips,
+			train: []Domino{{Left: 12, Right: 11}},
+			rest: []Domino{
+				{Left: 1, Right: 12},
+				{Left: 0, Right: 1},
+				{Left: 0, Right: 2},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		train, rest := LongestTrain(c.hand, c.start, c.m)
+		if !reflect.DeepEqual(train, c.train) || !reflect.DeepEqual(rest, c.rest) {
+			t.Errorf("%s: wanted %v %v, got %v %v", c.name, c.train, c.rest, train, rest)
+		}
+	}
+}
+
+// bruteTrain scores the best train by trying every order of tiles.
+func bruteTrain(hand []Domino, used []bool, end int, m Metric, tiles, pips int, last Domino) (int, int) {
+	bt, bp := 0, 0
+	if tiles > 0 && !last.IsDouble() {
+		bt, bp = tiles, pips
+	}
+
+	better := func(t, p int) bool {
+		a, b, ba, bb := t, p, bt, bp
+		if m == MostPips {
+			a, b, ba, bb = p, t, bp, bt
+		}
+		return a > ba || (a == ba && b > bb)
+	}
+
+	for i, d := range hand {
+		if used[i] || (d.Left != end && d.Right != end) {
+			continue
+		}
+
+		next := d.Right
+		if d.Left != end {
+			next = d.Left
+		}
+
+		used[i] = true
+		t, p := bruteTrain(hand, used, next, m, tiles+1, pips+d.Value(), d)
+		used[i] = false
+
+		if better(t, p) {
+			bt, bp = t, p
+		}
+	}
+
+	return bt, bp
+}
+
+func randomHand(r *rand.Rand, set, n int) []Domino {
+	var all []Domino
+	for i := 0; i <= set; i++ {
+		for j := 0; j <= i; j++ {
+			all = append(all, Domino{Left: i, Right: j})
+		}
+	}
+
+	var hand []Domino
+	for _, i := range r.Perm(len(all))[:n] {
+		hand = append(hand, all[i])
+	}
+	return hand
+}
+
+func TestLongestTrainIsBest(t *testing.T) {
+	r := rand.New(rand.NewSource(42))
+
+	for i := 0; i < 500; i++ {
+		hand := randomHand(r, 6, 9)
+		start := r.Intn(7)
+		m := Metric(i % 2)
+
+		train, rest := LongestTrain(hand, start, m)
+		if len(train)+len(rest) != len(hand) {
+			t.Fatalf("%v: lost tiles, %v %v", hand, train, rest)
+		}
+
+		pips, end := 0, start
+		for j, d := range train {
+			if d.Left != end || (j == len(train)-1 && d.IsDouble()) {
+				t.Fatalf("%v from %d: bad train %v", hand, start, train)
+			}
+			end = d.Right
+			pips += d.Value()
+		}
+
+		bt, bp := bruteTrain(hand, make([]bool, len(hand)), start, m, 0, 0, Domino{})
+		if bt != len(train) || bp != pips {
+			t.Fatalf("%v from %d: wanted %d tiles worth %d, got %v", hand, start, bt, bp, train)
+		}
+	}
+}
+
+// bigSets are hands big enough that LongestTrain may have to give up before
+// it finds the best train.
+var bigSets = []struct{ set, tiles int }{{12, 20}, {15, 25}, {18, 30}, {12, 40}}
+
+func TestLongestTrainBigSets(t *testing.T) {
+	r := rand.New(rand.NewSource(42))
+
+	for _, c := range bigSets {
+		for i := 0; i < 20; i++ {
+			hand, start, m := randomHand(r, c.set, c.tiles), r.Intn(c.set+1), Metric(i%2)
+
+			// Once the search runs out of steps, every tile still being
+			// looked at on the way back up costs one more.
+			s := newTrainSearch(hand, start, m)
+			s.topT, s.topP = s.bound(start)
+			s.walk(start)
+			if s.steps > maxTrainSteps+len(hand)*len(hand) {
+				t.Errorf("double-%d, %d tiles: searched for %d steps", c.set, c.tiles, s.steps)
+			}
+
+			train, rest := LongestTrain(hand, start, m)
+			if len(train)+len(rest) != len(hand) {
+				t.Fatalf("%v: lost tiles, %v %v", hand, train, rest)
+			}
+
+			end := start
+			for j, d := range train {
+				if d.Left != end || (j == len(train)-1 && d.IsDouble()) {
+					t.Fatalf("%v from %d: bad train %v", hand, start, train)
+				}
+				end = d.Right
+			}
+		}
+	}
+}
+
+func BenchmarkLongestTrain(b *testing.B) {
+	for _, c := range bigSets {
+		b.Run(fmt.Sprintf("double-%d/%d", c.set, c.tiles), func(b *testing.B) {
+			r := rand.New(rand.NewSource(42))
+			for i := 0; i < b.N; i++ {
+				LongestTrain(randomHand(r, c.set, c.tiles), r.Intn(c.set+1), Metric(i%2))
+			}
+		})
+	}
+}